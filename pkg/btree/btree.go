@@ -2,28 +2,94 @@
 package btree
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 	filemanager "godb/pkg/file"
 	"os"
-	"strconv"
 )
 
 const (
 	indexFileExt = ".idx"
 	nodeSize     = 48
 	// nodeSize = 6
+
+	// The header follows the root pointer: magic, key type, flags, 2 reserved bytes, buffer size.
+	// Index files created before the header have the first node right after the root pointer
+	headerPtr    = int64Length
+	headerLength = 16
+
+	flagNullable   = 1
+	flagDescending = 2
+)
+
+var headerMagic = []byte("GODBIDX1")
+
+// KeyType defines how the keys of the tree are compared
+type KeyType int
+
+// Key types
+const (
+	KeyText KeyType = iota
+	KeyInt
+	KeyReal
+	// KeyBinary keys are compared byte by byte, the caller encodes them order preserving
+	KeyBinary
 )
 
 // New creates a new balanced tree object
 func New(indexName string, bufSize int, intIndex bool) (BTree, error) {
 	if intIndex {
+		return NewTyped(indexName, bufSize, KeyInt)
+	}
+
+	return NewTyped(indexName, bufSize, KeyText)
+}
+
+// Options of the tree, the same options have to be used every time the index is opened
+type Options struct {
+	KeyType KeyType
+	// Nullable keys are prefixed with a flag byte (NullFlag / ValueFlag), null keys sort before any value
+	Nullable bool
+	// Descending trees return the greatest key first
+	Descending bool
+}
+
+// Nullable key flags
+const (
+	NullFlag  byte = 0
+	ValueFlag byte = 1
+)
+
+// Compare compares two keys in the order of a tree with the options, other ordered indexes use it to keep the same order
+func Compare(buf1, buf2 []byte, opts Options) int {
+	n := &Node{keyType: opts.KeyType, nullable: opts.Nullable, descending: opts.Descending}
+
+	return n.bytesCompare(buf1, buf2)
+}
+
+// NewTyped creates a new balanced tree object comparing it's keys by the key type
+func NewTyped(indexName string, bufSize int, keyType KeyType) (BTree, error) {
+	return NewWithOptions(indexName, bufSize, Options{KeyType: keyType})
+}
+
+// NewWithOptions creates a new balanced tree object with the options
+func NewWithOptions(indexName string, bufSize int, opts Options) (BTree, error) {
+	if opts.KeyType == KeyInt || opts.KeyType == KeyReal {
 		bufSize = int64Length
 	}
+
+	if opts.Nullable {
+		bufSize++
+	}
+
 	t := &Tree{
-		filer:     filemanager.New(),
-		indexName: indexName,
-		bufSize:   bufSize,
-		intIndex:  intIndex,
+		filer:      filemanager.New(),
+		indexName:  indexName,
+		bufSize:    bufSize,
+		keyType:    opts.KeyType,
+		nullable:   opts.Nullable,
+		descending: opts.Descending,
 	}
 
 	err := t.init()
@@ -39,10 +105,13 @@ type BTree interface {
 	First() (int64, *[]byte, error)
 	Last() (int64, *[]byte, error)
 	Search([]byte) (int64, *[]byte, bool, error)
+	Exists([]byte) (bool, error)
 	Next() (int64, *[]byte, bool, error)
 	Prev() (int64, *[]byte, bool, error)
-	Delete(int) bool
+	Delete([]byte, int64) error
+	Reset() error
 	Close() error
+	Cursor() BTree
 }
 
 // Tree represents the B-tree as a whole.
@@ -56,7 +125,9 @@ type Tree struct {
 	currentNode     *Node
 	currentNodeIdx  int
 	parentNodePtr   int64
-	intIndex        bool
+	keyType         KeyType
+	nullable        bool
+	descending      bool
 	latestNextIsEof bool
 }
 
@@ -83,6 +154,21 @@ func (t *Tree) Insert(key []byte, value int64) error {
 	return node.insert(key, value)
 }
 
+// Cursor returns a tree on the same file with it's own navigation state, so more cursors can walk the tree at the
+// same time. The cursor must not be closed, the file is closed by the tree
+func (t *Tree) Cursor() BTree {
+	return &Tree{
+		filer:          t.filer,
+		indexName:      t.indexName,
+		file:           t.file,
+		bufSize:        t.bufSize,
+		keyType:        t.keyType,
+		nullable:       t.nullable,
+		descending:     t.descending,
+		currentNodeIdx: -1,
+	}
+}
+
 // Close closes the Btree file
 func (t *Tree) Close() error {
 	return t.file.Close()
@@ -108,6 +194,21 @@ func (t *Tree) Search(key []byte) (int64, *[]byte, bool, error) {
 		return 0, nil, false, err
 	}
 
+	// Not found and the key is greater than every key of the leaf, the next greater key is in one of the parents
+	for !found && !node.data[idx].isSet {
+		if node.isRoot() {
+			// Greater than any key, stays on the last one
+			result, key, err := t.Last()
+			return result, key, false, err
+		}
+
+		err := node.load(node.parentNodePtr)
+		if err != nil {
+			return 0, nil, false, err
+		}
+		idx, _ = node.locateInData(&sk)
+	}
+
 	t.currentNode = node
 	t.currentNodeIdx = idx
 	result, _, err := node.getNextMapItem(idx)
@@ -115,6 +216,25 @@ func (t *Tree) Search(key []byte) (int64, *[]byte, bool, error) {
 	return result, &node.data[idx].data, found, err
 }
 
+// Exists reports if the key is in the tree, it does not move the index cursor
+func (t *Tree) Exists(key []byte) (bool, error) {
+	sk := make([]byte, t.bufSize)
+	copy(sk, key)
+
+	ptr, eof, err := t.filer.ReadInt64(t.file, 0)
+	if err != nil {
+		return false, err
+	}
+
+	if eof {
+		return false, fmt.Errorf("exists / cannot read root node pointer, corrupt index file")
+	}
+
+	_, _, found, err := t.recursiveSearch(ptr, &sk)
+
+	return found, err
+}
+
 // First sets the index cursor to the first element
 func (t *Tree) First() (int64, *[]byte, error) {
 	ptr, eof, err := t.filer.ReadInt64(t.file, 0)
@@ -401,9 +521,48 @@ func (t *Tree) recursiveSearch(ptr int64, s *[]byte) (*Node, int, bool, error) {
 	return sNode, i, false, nil
 }
 
-// Delete removes an element from the tree, not yet implemented
-func (t *Tree) Delete(key int) bool {
-	panic("Delete not yet implemented, cannot remove " + strconv.Itoa(key))
+// Delete removes a record pointer from the key, the key is removed from the tree when no more record points to it
+func (t *Tree) Delete(key []byte, value int64) error {
+	sk := make([]byte, t.bufSize)
+	copy(sk, key)
+
+	rootNodePtr, eof, err := t.filer.ReadInt64(t.file, 0)
+	if err != nil {
+		return err
+	}
+
+	if eof {
+		return fmt.Errorf("delete / cannot read root node pointer, corrupt index file")
+	}
+
+	node, idx, found, err := t.recursiveSearch(rootNodePtr, &sk)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		// nothing to remove
+		return nil
+	}
+
+	mapPtr, removed, err := node.removeFromMap(node.data[idx].mapPtr, value)
+	if err != nil {
+		return err
+	}
+
+	if !removed {
+		return nil
+	}
+
+	if mapPtr != 0 {
+		node.data[idx].mapPtr = mapPtr
+		node.data[idx].fetchMmapPtr = mapPtr
+
+		return node.update()
+	}
+
+	// It was the last record pointer of the key
+	return node.remove(idx)
 }
 
 func (t *Tree) init() error {
@@ -420,20 +579,114 @@ func (t *Tree) init() error {
 	t.file = file
 
 	if newFile {
-		parentNode := t.getNode(0)
-		err := parentNode.filer.WriteInt64(file, 0, int64(int64Length))
-		if err != nil {
-			return err
-		}
-		return parentNode.save(int64(int64Length))
+		return t.initRoot()
 	}
 
-	return nil
+	return t.readHeader()
 }
 
-func (t *Tree) getNode(parentNodePtr int64) *Node {
-	if t.intIndex {
-		return NewInt64Node(t.file, t.filer, nodeSize, parentNodePtr)
+// initRoot writes the root node pointer, the header and an empty root node to the blank index file
+func (t *Tree) initRoot() error {
+	parentNode := t.getNode(0)
+	rootPtr := int64(headerPtr + headerLength)
+	err := parentNode.filer.WriteInt64(t.file, 0, rootPtr)
+	if err != nil {
+		return err
+	}
+
+	err = t.filer.WriteBytes(t.file, headerPtr, t.header())
+	if err != nil {
+		return err
+	}
+
+	return parentNode.save(rootPtr)
+}
+
+func (t *Tree) header() []byte {
+	buf := make([]byte, headerLength)
+	copy(buf, headerMagic)
+	buf[8] = byte(t.keyType)
+	if t.nullable {
+		buf[9] |= flagNullable
+	}
+
+	if t.descending {
+		buf[9] |= flagDescending
+	}
+	binary.LittleEndian.PutUint32(buf[12:], uint32(t.bufSize))
+
+	return buf
+}
+
+// readHeader sets the options stored in the index file, files without header keep the options of the caller.
+// An empty tree is reinitialised with the options of the caller
+func (t *Tree) readHeader() error {
+	buf, eof, err := t.filer.ReadBytes(t.file, headerPtr, headerLength)
+	if err != nil {
+		return err
+	}
+
+	if eof || !bytes.Equal(buf[:len(headerMagic)], headerMagic) {
+		return nil
 	}
-	return NewNode(t.file, t.filer, nodeSize, t.bufSize, parentNodePtr)
+
+	callerHeader := t.header()
+	if bytes.Equal(buf, callerHeader) {
+		return nil
+	}
+
+	callerTree := *t
+	t.keyType = KeyType(buf[8])
+	t.nullable = buf[9]&flagNullable != 0
+	t.descending = buf[9]&flagDescending != 0
+	t.bufSize = int(binary.LittleEndian.Uint32(buf[12:]))
+
+	empty, err := t.isEmpty()
+	if err != nil || !empty {
+		return err
+	}
+
+	*t = callerTree
+
+	return t.Reset()
+}
+
+func (t *Tree) isEmpty() (bool, error) {
+	ptr, eof, err := t.filer.ReadInt64(t.file, 0)
+	if err != nil {
+		return false, err
+	}
+
+	if eof {
+		return false, fmt.Errorf("cannot read root node pointer, corrupt index file")
+	}
+
+	root := t.getNode(0)
+	err = root.load(ptr)
+	if err != nil {
+		return false, err
+	}
+
+	return root.itemCount() == 0, nil
+}
+
+// Reset truncates the index file and leaves an empty tree in it
+func (t *Tree) Reset() error {
+	err := t.file.Truncate(0)
+	if err != nil {
+		return err
+	}
+
+	t.currentNode = nil
+	t.currentNodeIdx = -1
+
+	return t.initRoot()
+}
+
+func (t *Tree) getNode(parentNodePtr int64) *Node {
+	node := newTypedNode(t.file, t.filer, nodeSize, t.bufSize, parentNodePtr, t.keyType)
+	node.nullable = t.nullable
+	node.descending = t.descending
+
+	return node
 }
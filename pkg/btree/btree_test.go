@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	filemanager "godb/pkg/file"
+	"math"
 	"os"
 	"strconv"
 	"testing"
@@ -97,6 +98,114 @@ func (t *btreeTestSuite) TestSearchNextWith1NodeMultipleValues() {
 	t.True(eof)
 }
 
+func (t *btreeTestSuite) TestDeleteRecordPointerFromKey() {
+	item := []byte("00010")
+	for i := 1; i <= 3; i++ {
+		err := t.tree.Insert(item, int64(i))
+		t.Nil(err)
+	}
+
+	err := t.tree.Delete(item, 1)
+	t.Nil(err)
+
+	res, _, found, err := t.tree.Search(item)
+	t.Nil(err)
+	t.True(found)
+	t.Equal(int64(2), res)
+
+	err = t.tree.Delete(item, 2)
+	t.Nil(err)
+	err = t.tree.Delete(item, 3)
+	t.Nil(err)
+
+	_, _, found, err = t.tree.Search(item)
+	t.Nil(err)
+	t.False(found)
+
+	_, dat, err := t.tree.First()
+	t.Nil(err)
+	t.Nil(dat)
+
+	err = t.tree.Insert(item, 4)
+	t.Nil(err)
+
+	res, _, found, err = t.tree.Search(item)
+	t.Nil(err)
+	t.True(found)
+	t.Equal(int64(4), res)
+}
+
+func (t *btreeTestSuite) TestDeleteKeysRebalancesTree() {
+	max := 5000
+	for i := 0; i < max; i++ {
+		err := t.tree.Insert([]byte(fmt.Sprintf("%05d", i)), int64(i))
+		t.Nil(err)
+	}
+
+	// Remove every odd key from the front, and every key from the upper half backwards
+	for i := 1; i < max/2; i += 2 {
+		err := t.tree.Delete([]byte(fmt.Sprintf("%05d", i)), int64(i))
+		t.Nil(err)
+	}
+
+	for i := max - 1; i >= max/2; i-- {
+		err := t.tree.Delete([]byte(fmt.Sprintf("%05d", i)), int64(i))
+		t.Nil(err)
+	}
+
+	for i := 0; i < max; i++ {
+		res, _, found, err := t.tree.Search([]byte(fmt.Sprintf("%05d", i)))
+		t.Nil(err)
+		if i < max/2 && i%2 == 0 {
+			t.True(found)
+			t.Equal(int64(i), res)
+			continue
+		}
+		t.False(found)
+	}
+
+	res, dat, err := t.tree.First()
+	t.Nil(err)
+	t.Equal("00000", string(*dat))
+	t.Equal(int64(0), res)
+
+	expected := 2
+	for {
+		res, dat, eof, err := t.tree.Next()
+		t.Nil(err)
+		if eof {
+			break
+		}
+		t.Equal(fmt.Sprintf("%05d", expected), string(*dat))
+		t.Equal(int64(expected), res)
+		expected += 2
+	}
+	t.Equal(max/2, expected)
+
+	res, dat, err = t.tree.Last()
+	t.Nil(err)
+	t.Equal(fmt.Sprintf("%05d", max/2-2), string(*dat))
+	t.Equal(int64(max/2-2), res)
+
+	// Empty the tree completely
+	for i := 0; i < max/2; i += 2 {
+		err := t.tree.Delete([]byte(fmt.Sprintf("%05d", i)), int64(i))
+		t.Nil(err)
+	}
+
+	_, dat, err = t.tree.First()
+	t.Nil(err)
+	t.Nil(dat)
+
+	err = t.tree.Insert([]byte("00042"), 42)
+	t.Nil(err)
+
+	res, _, found, err := t.tree.Search([]byte("00042"))
+	t.Nil(err)
+	t.True(found)
+	t.Equal(int64(42), res)
+}
+
 func (t *btreeTestSuite) TestAllINdexedItemCanBeFound() {
 	for i := 10000; i > 0; i-- {
 		t.tree.Insert([]byte(fmt.Sprintf("%05d", i)), int64(i+5))
@@ -251,6 +360,210 @@ func (t *btreeTestSuite) TestPrevWithInt() {
 	}
 }
 
+func (t *btreeTestSuite) TestRealKeysOrder() {
+	var err error
+	t.tree, err = NewTyped("test_real_index", 0, KeyReal)
+	if err != nil {
+		panic(err)
+	}
+
+	for i := 500; i > -500; i-- {
+		buf := make([]byte, 8)
+		binary.LittleEndian.PutUint64(buf, math.Float64bits(float64(i)/4))
+		err := t.tree.Insert(buf, int64(i))
+		t.Nil(err)
+	}
+
+	res, _, err := t.tree.First()
+	t.Nil(err)
+	t.Equal(int64(-499), res)
+
+	num := int64(-498)
+	for {
+		res, _, eof, err := t.tree.Next()
+		t.Nil(err)
+		if eof {
+			break
+		}
+		t.Equal(num, res)
+		num++
+	}
+	t.Equal(int64(501), num)
+}
+
+func (t *btreeTestSuite) TestNullableKeysSortFirst() {
+	var err error
+	t.tree, err = NewWithOptions("test_nullable_index", 0, Options{KeyType: KeyInt, Nullable: true})
+	if err != nil {
+		panic(err)
+	}
+
+	for i := 100; i > -100; i-- {
+		buf := []byte{ValueFlag, 0, 0, 0, 0, 0, 0, 0, 0}
+		binary.LittleEndian.PutUint64(buf[1:], uint64(i))
+		if i%10 == 0 {
+			buf = []byte{NullFlag}
+		}
+
+		err := t.tree.Insert(buf, int64(i))
+		t.Nil(err)
+	}
+
+	nulls := 0
+	res, key, err := t.tree.First()
+	t.Nil(err)
+	for (*key)[0] == NullFlag {
+		t.Equal(int64(0), res%10)
+		nulls++
+
+		var eof bool
+		res, key, eof, err = t.tree.Next()
+		t.Nil(err)
+		t.False(eof)
+	}
+	t.Equal(20, nulls)
+	t.Equal(int64(-99), res)
+
+	res, _, found, err := t.tree.Search([]byte{NullFlag})
+	t.Nil(err)
+	t.True(found)
+	t.Equal(int64(0), res%10)
+}
+
+func (t *btreeTestSuite) TestExistsKeepsCursor() {
+	for i := 0; i < 500; i++ {
+		err := t.tree.Insert([]byte(fmt.Sprintf("%05d", i)), int64(i))
+		t.Nil(err)
+	}
+
+	res, _, found, err := t.tree.Search([]byte("00100"))
+	t.Nil(err)
+	t.True(found)
+	t.Equal(int64(100), res)
+
+	found, err = t.tree.Exists([]byte("00499"))
+	t.Nil(err)
+	t.True(found)
+
+	found, err = t.tree.Exists([]byte("00500"))
+	t.Nil(err)
+	t.False(found)
+
+	res, _, _, err = t.tree.Next()
+	t.Nil(err)
+	t.Equal(int64(101), res)
+}
+
+func (t *btreeTestSuite) TestSearchPositionsOnNextGreaterKey() {
+	for i := 0; i <= 2000; i += 2 {
+		err := t.tree.Insert([]byte(fmt.Sprintf("%05d", i)), int64(i))
+		t.Nil(err)
+	}
+
+	for i := 1; i < 2000; i += 2 {
+		res, _, found, err := t.tree.Search([]byte(fmt.Sprintf("%05d", i)))
+		t.Nil(err)
+		t.False(found)
+		t.Equal(int64(i+1), res)
+
+		res, _, eof, err := t.tree.Next()
+		t.Nil(err)
+		if i == 1999 {
+			t.True(eof)
+			continue
+		}
+		t.False(eof)
+		t.Equal(int64(i+3), res)
+	}
+
+	res, _, found, err := t.tree.Search([]byte("03000"))
+	t.Nil(err)
+	t.False(found)
+	t.Equal(int64(2000), res)
+}
+
+func (t *btreeTestSuite) TestDescendingOptionIsStoredInHeader() {
+	var err error
+	t.tree, err = NewWithOptions("test_desc_index", 0, Options{KeyType: KeyInt, Descending: true})
+	if err != nil {
+		panic(err)
+	}
+
+	for i := -100; i < 100; i++ {
+		err := t.tree.Insert(t.int64ToBuf(int64(i)), int64(i))
+		t.Nil(err)
+	}
+	t.Nil(t.tree.Close())
+
+	// Reopened with the default options, the header keeps the comparator
+	t.tree, err = New("test_desc_index", 8, true)
+	if err != nil {
+		panic(err)
+	}
+
+	res, _, err := t.tree.First()
+	t.Nil(err)
+	t.Equal(int64(99), res)
+
+	num := int64(98)
+	for {
+		res, _, eof, err := t.tree.Next()
+		t.Nil(err)
+		if eof {
+			break
+		}
+		t.Equal(num, res)
+		num--
+	}
+	t.Equal(int64(-101), num)
+
+	res, _, err = t.tree.Last()
+	t.Nil(err)
+	t.Equal(int64(-100), res)
+
+	res, _, found, err := t.tree.Search(t.int64ToBuf(-50))
+	t.Nil(err)
+	t.True(found)
+	t.Equal(int64(-50), res)
+
+	// Reset keeps the options too
+	t.Nil(t.tree.Reset())
+	err = t.tree.Insert(t.int64ToBuf(1), 1)
+	t.Nil(err)
+	err = t.tree.Insert(t.int64ToBuf(2), 2)
+	t.Nil(err)
+	res, _, err = t.tree.First()
+	t.Nil(err)
+	t.Equal(int64(2), res)
+}
+
+func (t *btreeTestSuite) TestLegacyIndexWithoutHeader() {
+	filer := filemanager.New()
+	_, err := filer.CreateBlankFileIfNotExist("test_legacy_index.idx")
+	t.Nil(err)
+	file, err := filer.OpenReadWrite("test_legacy_index.idx")
+	t.Nil(err)
+	t.Nil(filer.WriteInt64(file, 0, 8))
+	node := NewInt64Node(file, filer, nodeSize, 0)
+	t.Nil(node.save(8))
+	t.Nil(file.Close())
+
+	t.tree, err = New("test_legacy_index", 8, true)
+	if err != nil {
+		panic(err)
+	}
+
+	for i := 0; i < 300; i++ {
+		err := t.tree.Insert(t.int64ToBuf(int64(300-i)), int64(i))
+		t.Nil(err)
+	}
+
+	res, key, err := t.tree.First()
+	t.Nil(err)
+	t.Equal(int64(299), res)
+	t.Equal(int64(1), t.bufToInt64(*key))
+}
+
 func (t *btreeTestSuite) log(s ...interface{}) {
 
 	// filer := filemanager.New()
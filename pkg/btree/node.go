@@ -1,10 +1,12 @@
 package btree
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
 	filemanager "godb/pkg/file"
 	"io"
+	"math"
 	"os"
 	"strings"
 )
@@ -20,15 +22,20 @@ const (
 
 // NewNode creates a new BTree node instance
 func NewNode(file *os.File, filer filemanager.Filer, elementSize, bufSize int, parentNodePtr int64) *Node {
-	return newTypedNode(file, filer, elementSize, bufSize, parentNodePtr, false)
+	return newTypedNode(file, filer, elementSize, bufSize, parentNodePtr, KeyText)
 }
 
 // NewInt64Node creates a new 64 bit integer index node instance
 func NewInt64Node(file *os.File, filer filemanager.Filer, elementSize int, parentNodePtr int64) *Node {
-	return newTypedNode(file, filer, elementSize, int64Length, parentNodePtr, true)
+	return newTypedNode(file, filer, elementSize, int64Length, parentNodePtr, KeyInt)
 }
 
-func newTypedNode(file *os.File, filer filemanager.Filer, elementSize, bufSize int, parentNodePtr int64, isIntNode bool) *Node {
+// NewRealNode creates a new 64 bit floating point index node instance
+func NewRealNode(file *os.File, filer filemanager.Filer, elementSize int, parentNodePtr int64) *Node {
+	return newTypedNode(file, filer, elementSize, int64Length, parentNodePtr, KeyReal)
+}
+
+func newTypedNode(file *os.File, filer filemanager.Filer, elementSize, bufSize int, parentNodePtr int64, keyType KeyType) *Node {
 	// Adding extra element, if node is full still can add element order in then we will split it before saving
 
 	data := make([]DataItem, elementSize+1)
@@ -43,7 +50,7 @@ func newTypedNode(file *os.File, filer filemanager.Filer, elementSize, bufSize i
 		bufSize:         bufSize,
 		parentNodePtr:   parentNodePtr,
 		bfLen:           bfLen,
-		isIntNode:       isIntNode,
+		keyType:         keyType,
 	}
 }
 
@@ -60,7 +67,9 @@ type Node struct {
 	bufSize         int
 	bfLen           int
 	itemIndex       int
-	isIntNode       bool
+	keyType         KeyType
+	nullable        bool
+	descending      bool
 }
 
 // DataItem is a data with it's right node pointer
@@ -83,7 +92,9 @@ func (n *Node) add(parentNodePtr int64) *Node {
 		bufSize:         n.bufSize,
 		parentNodePtr:   parentNodePtr,
 		bfLen:           n.bfLen,
-		isIntNode:       n.isIntNode,
+		keyType:         n.keyType,
+		nullable:        n.nullable,
+		descending:      n.descending,
 	}
 }
 
@@ -232,11 +243,6 @@ func (n *Node) insertWithPointer(item []byte, childPtr, mapValue int64, movedFro
 	return nil
 }
 
-// func (n *Node) remove(ptr int64) {
-// 	// Remove element from node if it does not go under minimum
-// 	panic("Remove element not implemented yet")
-// }
-
 func (n *Node) itemCount() int {
 	cnt := 0
 	for _, d := range n.data {
@@ -265,10 +271,6 @@ func (n *Node) needToSplit() bool {
 	return n.itemCount() == n.maxElementCount+1
 }
 
-// func (n *Node) needToMerge() bool {
-// 	return n.itemCount() < n.minElementCount && !n.isRoot()
-// }
-
 func (n *Node) isLeaf() bool {
 	return n.data[0].isSet && n.data[0].children == 0
 }
@@ -378,12 +380,13 @@ func (n *Node) splitRegularNode() error {
 	// n.updateAllChildParentPointer() // This probably stays intact
 	// parentNode.updateAllChildParentPointer() // This is rather handled in the insert with pointer, so save some file operations
 
-	err = parentNode.insertWithPointer(middleElement.data, rightNodeOffset, middleElement.mapPtr, n)
+	// Save before touching the parent, if the parent splits this node may get a new parent pointer written to the file
+	err = n.update()
 	if err != nil {
 		return err
 	}
 
-	return n.update()
+	return parentNode.insertWithPointer(middleElement.data, rightNodeOffset, middleElement.mapPtr, n)
 }
 
 func (n *Node) updateAllChildParentPointer() error {
@@ -504,12 +507,57 @@ func (n *Node) readOffsetAsBool(from *[]byte, fromIndex int) (bool, int) {
 }
 
 func (n *Node) bytesCompare(buf1, buf2 []byte) int {
-	if !n.isIntNode {
-		return n.stringCompare(&buf1, &buf2)
-		// lets try null terminated string compare
-		// return bytes.Compare(buf1, buf2)
+	result := 0
+	if n.nullable {
+		result = n.nullableCompare(buf1, buf2)
+	} else {
+		result = n.keyCompare(buf1, buf2)
 	}
 
+	if n.descending {
+		return -result
+	}
+
+	return result
+}
+
+// nullableCompare compares the null flags first, nulls are equal to each other and less than any value
+func (n *Node) nullableCompare(buf1, buf2 []byte) int {
+	if len(buf1) == 0 {
+		return isLess
+	}
+
+	if buf1[0] == NullFlag || buf2[0] == NullFlag {
+		if buf1[0] == buf2[0] {
+			return isEqual
+		}
+
+		if buf1[0] == NullFlag {
+			return isLess
+		}
+
+		return isGreater
+	}
+
+	return n.keyCompare(buf1[1:], buf2[1:])
+}
+
+func (n *Node) keyCompare(buf1, buf2 []byte) int {
+	switch n.keyType {
+	case KeyInt:
+		return n.int64Compare(buf1, buf2)
+	case KeyReal:
+		return n.realCompare(buf1, buf2)
+	case KeyBinary:
+		return bytes.Compare(buf1, buf2)
+	}
+
+	return n.stringCompare(&buf1, &buf2)
+	// lets try null terminated string compare
+	// return bytes.Compare(buf1, buf2)
+}
+
+func (n *Node) int64Compare(buf1, buf2 []byte) int {
 	if len(buf1) == 0 {
 		return isLess
 	}
@@ -526,6 +574,41 @@ func (n *Node) bytesCompare(buf1, buf2 []byte) int {
 
 	return isGreater
 }
+
+// realCompare compares IEEE-754 float64 keys, NaN is equal to NaN and sorts before any number
+func (n *Node) realCompare(buf1, buf2 []byte) int {
+	if len(buf1) == 0 {
+		return isLess
+	}
+
+	f1 := math.Float64frombits(binary.LittleEndian.Uint64(buf1))
+	f2 := math.Float64frombits(binary.LittleEndian.Uint64(buf2))
+
+	nan1 := math.IsNaN(f1)
+	nan2 := math.IsNaN(f2)
+	if nan1 || nan2 {
+		if nan1 && nan2 {
+			return isEqual
+		}
+
+		if nan1 {
+			return isLess
+		}
+
+		return isGreater
+	}
+
+	if f1 == f2 {
+		return isEqual
+	}
+
+	if f1 < f2 {
+		return isLess
+	}
+
+	return isGreater
+}
+
 func (n *Node) stringCompare(buf1, buf2 *[]byte) int {
 	s1 := n.bufToStr(buf1)
 	s2 := n.bufToStr(buf2)
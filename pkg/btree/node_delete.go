@@ -0,0 +1,260 @@
+package btree
+
+// remove drops the item from the node, then rebalances the tree if the node went under the minimum element count
+func (n *Node) remove(itemInd int) error {
+	if n.leftChild == 0 {
+		n.removeItem(itemInd)
+		err := n.update()
+		if err != nil {
+			return err
+		}
+
+		return n.rebalance()
+	}
+
+	// Non leaf node, replace the item with it's successor, the first item of the leftmost leaf of the right subtree
+	leaf := n.add(0)
+	err := leaf.load(n.data[itemInd].children)
+	if err != nil {
+		return err
+	}
+
+	for leaf.leftChild != 0 {
+		err := leaf.load(leaf.leftChild)
+		if err != nil {
+			return err
+		}
+	}
+
+	n.data[itemInd].data = leaf.data[0].data
+	n.data[itemInd].mapPtr = leaf.data[0].mapPtr
+	n.data[itemInd].fetchMmapPtr = leaf.data[0].mapPtr
+	err = n.update()
+	if err != nil {
+		return err
+	}
+
+	leaf.removeItem(0)
+	err = leaf.update()
+	if err != nil {
+		return err
+	}
+
+	return leaf.rebalance()
+}
+
+// removeItem shifts the items after the index to the left, the last one gets cleared
+func (n *Node) removeItem(itemInd int) {
+	last := len(n.data) - 1
+	for i := itemInd; i < last; i++ {
+		n.data[i] = n.data[i+1]
+	}
+
+	n.data[last] = DataItem{data: make([]byte, n.bufSize)}
+}
+
+func (n *Node) needToMerge() bool {
+	return n.itemCount() < n.minElementCount && !n.isRoot()
+}
+
+func (n *Node) rebalance() error {
+	if n.isRoot() {
+		return n.shrinkRoot()
+	}
+
+	if !n.needToMerge() {
+		return nil
+	}
+
+	parentNode := n.add(0)
+	err := parentNode.load(n.parentNodePtr)
+	if err != nil {
+		return err
+	}
+
+	pos := parentNode.childPosition(n.currentPtr)
+	var leftNode, rightNode *Node
+
+	if pos >= 0 {
+		leftNode = n.add(n.parentNodePtr)
+		err := leftNode.load(parentNode.childPtr(pos - 1))
+		if err != nil {
+			return err
+		}
+
+		if leftNode.itemCount() > n.minElementCount {
+			return n.borrowFromLeft(parentNode, leftNode, pos)
+		}
+	}
+
+	if pos+1 < parentNode.itemCount() {
+		rightNode = n.add(n.parentNodePtr)
+		err := rightNode.load(parentNode.childPtr(pos + 1))
+		if err != nil {
+			return err
+		}
+
+		if rightNode.itemCount() > n.minElementCount {
+			return n.borrowFromRight(parentNode, rightNode, pos+1)
+		}
+	}
+
+	if leftNode != nil {
+		return leftNode.mergeWithRight(parentNode, n, pos)
+	}
+
+	if rightNode != nil {
+		return n.mergeWithRight(parentNode, rightNode, pos+1)
+	}
+
+	return nil
+}
+
+// shrinkRoot makes the only child the new root when the root ran out of items
+func (n *Node) shrinkRoot() error {
+	if n.itemCount() > 0 || n.leftChild == 0 {
+		return nil
+	}
+
+	childNode := n.add(0)
+	err := childNode.load(n.leftChild)
+	if err != nil {
+		return err
+	}
+
+	childNode.parentNodePtr = 0
+	err = childNode.update()
+	if err != nil {
+		return err
+	}
+
+	return childNode.setRoot()
+}
+
+// borrowFromLeft rotates the last item of the left sibling through the parent into this node
+func (n *Node) borrowFromLeft(parentNode, leftNode *Node, separatorInd int) error {
+	lastInd := leftNode.itemCount() - 1
+	lastItem := leftNode.data[lastInd]
+	separator := parentNode.data[separatorInd]
+
+	for i := len(n.data) - 1; i > 0; i-- {
+		n.data[i] = n.data[i-1]
+	}
+
+	n.data[0] = DataItem{
+		data:         separator.data,
+		children:     n.leftChild,
+		mapPtr:       separator.mapPtr,
+		fetchMmapPtr: separator.mapPtr,
+		isSet:        true,
+	}
+	n.leftChild = lastItem.children
+
+	parentNode.data[separatorInd].data = lastItem.data
+	parentNode.data[separatorInd].mapPtr = lastItem.mapPtr
+	parentNode.data[separatorInd].fetchMmapPtr = lastItem.mapPtr
+
+	leftNode.removeItem(lastInd)
+
+	return n.saveRotation(parentNode, leftNode, n.leftChild)
+}
+
+// borrowFromRight rotates the first item of the right sibling through the parent into this node
+func (n *Node) borrowFromRight(parentNode, rightNode *Node, separatorInd int) error {
+	firstItem := rightNode.data[0]
+	separator := parentNode.data[separatorInd]
+	movedChild := rightNode.leftChild
+
+	n.data[n.itemCount()] = DataItem{
+		data:         separator.data,
+		children:     movedChild,
+		mapPtr:       separator.mapPtr,
+		fetchMmapPtr: separator.mapPtr,
+		isSet:        true,
+	}
+
+	parentNode.data[separatorInd].data = firstItem.data
+	parentNode.data[separatorInd].mapPtr = firstItem.mapPtr
+	parentNode.data[separatorInd].fetchMmapPtr = firstItem.mapPtr
+
+	rightNode.leftChild = firstItem.children
+	rightNode.removeItem(0)
+
+	return n.saveRotation(parentNode, rightNode, movedChild)
+}
+
+func (n *Node) saveRotation(parentNode, siblingNode *Node, movedChild int64) error {
+	for _, node := range []*Node{n, parentNode, siblingNode} {
+		err := node.update()
+		if err != nil {
+			return err
+		}
+	}
+
+	if movedChild == 0 {
+		return nil
+	}
+
+	// The child moved over from the sibling
+	return n.filer.WriteInt64(n.file, movedChild, n.currentPtr)
+}
+
+// mergeWithRight pulls down the separator from the parent and moves all items of the right sibling into this node
+func (n *Node) mergeWithRight(parentNode, rightNode *Node, separatorInd int) error {
+	count := n.itemCount()
+	separator := parentNode.data[separatorInd]
+
+	n.data[count] = DataItem{
+		data:         separator.data,
+		children:     rightNode.leftChild,
+		mapPtr:       separator.mapPtr,
+		fetchMmapPtr: separator.mapPtr,
+		isSet:        true,
+	}
+
+	for i := 0; i < rightNode.itemCount(); i++ {
+		n.data[count+1+i] = rightNode.data[i]
+	}
+
+	parentNode.removeItem(separatorInd)
+
+	err := n.update()
+	if err != nil {
+		return err
+	}
+
+	err = parentNode.update()
+	if err != nil {
+		return err
+	}
+
+	err = n.updateAllChildParentPointer()
+	if err != nil {
+		return err
+	}
+
+	return parentNode.rebalance()
+}
+
+// childPosition returns the index of the item holding the child pointer, -1 is the left child
+func (n *Node) childPosition(childPtr int64) int {
+	if n.leftChild == childPtr {
+		return -1
+	}
+
+	for i, dat := range n.data {
+		if dat.isSet && dat.children == childPtr {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (n *Node) childPtr(itemInd int) int64 {
+	if itemInd < 0 {
+		return n.leftChild
+	}
+
+	return n.data[itemInd].children
+}
@@ -42,6 +42,31 @@ func (n *Node) insertToMap(ptr, val int64) error {
 	}
 }
 
+// removeFromMap unlinks a value from the mapping chain, returns the (new) first element pointer of the chain
+func (n *Node) removeFromMap(ptr, val int64) (int64, bool, error) {
+	firstPtr := ptr
+	var prevPtr int64
+	for ptr != 0 {
+		currentVal, nextElementPtr, err := n.getMapItem(ptr)
+		if err != nil {
+			return firstPtr, false, err
+		}
+
+		if currentVal == val {
+			if prevPtr == 0 {
+				return nextElementPtr, true, nil
+			}
+
+			return firstPtr, true, n.filer.WriteInt64(n.file, prevPtr+int64Length, nextElementPtr)
+		}
+
+		prevPtr = ptr
+		ptr = nextElementPtr
+	}
+
+	return firstPtr, false, nil
+}
+
 func (n *Node) getMapItem(ptr int64) (int64, int64, error) {
 	buf, eof, err := n.filer.ReadBytes(n.file, ptr, int64Length*2)
 	if err != nil {
@@ -0,0 +1,141 @@
+package localdb
+
+import (
+	filemanager "godb/pkg/file"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type binaryTreeTestSuite struct {
+	suite.Suite
+	db Manager
+	ct *CurrentTable
+}
+
+func TestBinaryTreeRunner(t *testing.T) {
+	suite.Run(t, new(binaryTreeTestSuite))
+}
+
+func (t *binaryTreeTestSuite) SetupTest() {
+	err := os.RemoveAll(filemanager.DefaultFolder)
+	if err != nil {
+		panic("Cannot run test, the folder cannot be removed " + err.Error())
+	}
+
+	t.db = New()
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "name", Type: FtText, Length: 10, Indexes: []IndexDef{{Name: "bin_name", Type: IndexTypeBinaryTree}}},
+			{Name: "qty", Type: FtInt, Nullable: true, Indexes: []IndexDef{{Name: "bin_qty", Type: IndexTypeBinaryTree, Descending: true}}},
+		},
+		Indexes: []IndexDef{{Name: "bin_upper", Type: IndexTypeBinaryTree, Expression: "UPPER(name)"}},
+	}
+	tableName := "bintree_tests"
+	err = t.db.Create(tableName, tableStruct)
+	if err != nil {
+		panic("Cannot run test, Could not create database " + err.Error())
+	}
+
+	ct, err := t.db.Open(tableName)
+	if err != nil {
+		panic("Cannot open table " + err.Error())
+	}
+
+	t.ct = ct
+
+	rows := []map[string]interface{}{
+		{"name": "mike", "qty": 5},
+		{"name": "Dora", "qty": nil},
+		{"name": "tom", "qty": 12},
+		{"name": "anna", "qty": 5},
+		{"name": "bob", "qty": 1},
+	}
+	for _, row := range rows {
+		_, err = t.db.Insert(t.ct, row)
+		if err != nil {
+			panic("Cannot insert test data " + err.Error())
+		}
+	}
+}
+
+func (t *binaryTreeTestSuite) TearDownTest() {
+	t.ct.Close()
+	t.db = nil
+}
+
+func (t *binaryTreeTestSuite) walk(indexName string) []int64 {
+	err := t.db.Use(t.ct, indexName)
+	t.Nil(err)
+
+	recNos := make([]int64, 0)
+	err = t.db.First(t.ct)
+	t.Nil(err)
+
+	for {
+		recNos = append(recNos, t.ct.CursorPos())
+		eof, err := t.db.Next(t.ct)
+		t.Nil(err)
+		if eof {
+			return recNos
+		}
+	}
+}
+
+func (t *binaryTreeTestSuite) TestOrder() {
+	t.Equal([]int64{1, 3, 4, 0, 2}, t.walk("bin_name"))
+	t.Equal([]int64{3, 4, 1, 0, 2}, t.walk("bin_upper"))
+	t.Equal([]int64{2, 0, 3, 4, 1}, t.walk("bin_qty"))
+
+	err := t.db.Last(t.ct)
+	t.Nil(err)
+	t.Equal(int64(1), t.ct.CursorPos())
+}
+
+func (t *binaryTreeTestSuite) TestSeekAndLocate() {
+	err := t.db.Use(t.ct, "bin_name")
+	t.Nil(err)
+
+	found, err := t.db.Seek(t.ct, "bob")
+	t.Nil(err)
+	t.True(found)
+	t.Equal(int64(4), t.ct.CursorPos())
+
+	t.ct.SetSoftSeek(true)
+	found, err = t.db.Seek(t.ct, "c")
+	t.Nil(err)
+	t.False(found)
+	t.Equal(int64(0), t.ct.CursorPos())
+
+	res, err := t.db.Locate(t.ct, "name", "tom")
+	t.Nil(err)
+	t.Equal(int64(2), res["_recNo"])
+
+	err = t.db.Use(t.ct, "bin_upper")
+	t.Nil(err)
+	_, err = t.db.Seek(t.ct, "dora")
+	t.Nil(err)
+	t.Equal(int64(1), t.ct.CursorPos())
+
+	err = t.db.Use(t.ct, "bin_qty")
+	t.Nil(err)
+	_, err = t.db.Seek(t.ct, nil)
+	t.Nil(err)
+	t.Equal(int64(1), t.ct.CursorPos())
+}
+
+func (t *binaryTreeTestSuite) TestUpdateDeleteAndPack() {
+	err := t.db.Update(t.ct, 2, map[string]interface{}{"name": "adam"})
+	t.Nil(err)
+
+	err = t.db.Delete(t.ct, 0)
+	t.Nil(err)
+	t.Equal([]int64{1, 2, 3, 4}, t.walk("bin_name"))
+
+	_, err = t.db.Pack(t.ct)
+	t.Nil(err)
+	t.Equal([]int64{0, 1, 2, 3}, t.walk("bin_name"))
+	t.Equal([]int64{1, 2, 3, 0}, t.walk("bin_upper"))
+	t.Equal([]int64{1, 2, 3, 0}, t.walk("bin_qty"))
+}
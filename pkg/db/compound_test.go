@@ -0,0 +1,171 @@
+package localdb
+
+import (
+	filemanager "godb/pkg/file"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type compoundTestSuite struct {
+	suite.Suite
+	db Manager
+	ct *CurrentTable
+}
+
+func TestCompoundRunner(t *testing.T) {
+	suite.Run(t, new(compoundTestSuite))
+}
+
+func (t *compoundTestSuite) SetupTest() {
+	err := os.RemoveAll(filemanager.DefaultFolder)
+	if err != nil {
+		panic("Cannot run test, the folder cannot be removed " + err.Error())
+	}
+
+	t.db = New()
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "last_name", Type: FtText, Length: 10},
+			{Name: "first_name", Type: FtText, Length: 10},
+			{Name: "birth_date", Type: FtDate},
+			{Name: "score", Type: FtReal, Nullable: true},
+		},
+		Indexes: []IndexDef{
+			{Name: "compound_name", Fields: []string{"last_name", "first_name", "birth_date"}, Unique: true},
+			{Name: "compound_score", Fields: []string{"score", "last_name"}},
+		},
+	}
+	tableName := "compound_tests"
+	err = t.db.Create(tableName, tableStruct)
+	if err != nil {
+		panic("Cannot run test, Could not create database " + err.Error())
+	}
+
+	ct, err := t.db.Open(tableName)
+	if err != nil {
+		panic("Cannot open table " + err.Error())
+	}
+
+	t.ct = ct
+
+	rows := []map[string]interface{}{
+		{"last_name": "smith", "first_name": "john", "birth_date": "1990-05-01", "score": 2.5},
+		{"last_name": "brown", "first_name": "anna", "birth_date": "1985-01-01", "score": -1.5},
+		{"last_name": "smith", "first_name": "john", "birth_date": "1970-12-31", "score": nil},
+		{"last_name": "smith", "first_name": "adam", "birth_date": "2000-02-02", "score": -1.5},
+		{"last_name": "smi", "first_name": "zed", "birth_date": "1999-09-09", "score": 10},
+	}
+	for _, row := range rows {
+		_, err = t.db.Insert(t.ct, row)
+		if err != nil {
+			panic("Cannot insert test data " + err.Error())
+		}
+	}
+}
+
+func (t *compoundTestSuite) TearDownTest() {
+	t.ct.Close()
+	t.db = nil
+}
+
+func (t *compoundTestSuite) walk() []int64 {
+	recNos := make([]int64, 0)
+	err := t.db.First(t.ct)
+	t.Nil(err)
+
+	for {
+		recNos = append(recNos, t.ct.CursorPos())
+		eof, err := t.db.Next(t.ct)
+		t.Nil(err)
+		if eof {
+			return recNos
+		}
+	}
+}
+
+func (t *compoundTestSuite) TestOrder() {
+	err := t.db.Use(t.ct, "compound_name")
+	t.Nil(err)
+	t.Equal([]int64{1, 4, 3, 2, 0}, t.walk())
+
+	// Nulls first, negative numbers before positive ones, ties ordered by the next field
+	err = t.db.Use(t.ct, "compound_score")
+	t.Nil(err)
+	t.Equal([]int64{2, 1, 3, 0, 4}, t.walk())
+
+	// Indexes are opened from the definition
+	err = t.ct.Close()
+	t.Nil(err)
+	t.ct, err = t.db.Open("compound_tests")
+	t.Nil(err)
+
+	err = t.db.Use(t.ct, "compound_name")
+	t.Nil(err)
+	t.Equal([]int64{1, 4, 3, 2, 0}, t.walk())
+}
+
+func (t *compoundTestSuite) TestSeekPrefix() {
+	err := t.db.Use(t.ct, "compound_name")
+	t.Nil(err)
+
+	_, err = t.db.Seek(t.ct, []interface{}{"smith"})
+	t.Nil(err)
+	t.Equal(int64(3), t.ct.CursorPos())
+
+	_, err = t.db.Seek(t.ct, "smith")
+	t.Nil(err)
+	t.Equal(int64(3), t.ct.CursorPos())
+
+	_, err = t.db.Seek(t.ct, []interface{}{"smith", "john"})
+	t.Nil(err)
+	t.Equal(int64(2), t.ct.CursorPos())
+
+	eof, err := t.db.Next(t.ct)
+	t.Nil(err)
+	t.False(eof)
+	t.Equal(int64(0), t.ct.CursorPos())
+
+	_, err = t.db.Seek(t.ct, []interface{}{"smith", "john", "1990-05-01"})
+	t.Nil(err)
+	t.Equal(int64(0), t.ct.CursorPos())
+
+	_, err = t.db.Seek(t.ct, []interface{}{"smith", "john", "1990-05-01", "extra"})
+	t.Error(err)
+}
+
+func (t *compoundTestSuite) TestUpdateDeleteAndUnique() {
+	_, err := t.db.Insert(t.ct, map[string]interface{}{"last_name": "smith", "first_name": "john", "birth_date": "1990-05-01"})
+	t.ErrorIs(err, ErrDuplicateKey)
+
+	err = t.db.Update(t.ct, 4, map[string]interface{}{"last_name": "adams"})
+	t.Nil(err)
+
+	err = t.db.Delete(t.ct, 3)
+	t.Nil(err)
+
+	err = t.db.Use(t.ct, "compound_name")
+	t.Nil(err)
+	t.Equal([]int64{4, 1, 2, 0}, t.walk())
+
+	err = t.db.Use(t.ct, "compound_score")
+	t.Nil(err)
+	t.Equal([]int64{2, 1, 0, 4}, t.walk())
+
+	err = t.db.Update(t.ct, 1, map[string]interface{}{"last_name": "smith", "first_name": "john", "birth_date": "1970-12-31"})
+	t.ErrorIs(err, ErrDuplicateKey)
+}
+
+func (t *compoundTestSuite) TestInvalidDefinition() {
+	tableStruct := &FieldDef{
+		Fields:  []Field{{Name: "name", Type: FtText, Length: 10}},
+		Indexes: []IndexDef{{Name: "compound_unknown", Fields: []string{"name", "missing"}}},
+	}
+	err := t.db.Create("compound_invalid", tableStruct)
+	t.Error(err)
+
+	tableStruct.Indexes = []IndexDef{{Name: "compound_empty"}}
+	err = t.db.Create("compound_invalid", tableStruct)
+	t.Error(err)
+}
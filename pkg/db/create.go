@@ -2,7 +2,7 @@ package localdb
 
 import (
 	"encoding/json"
-	"godb/pkg/btree"
+	"fmt"
 	filemanager "godb/pkg/file"
 	"os"
 )
@@ -30,14 +30,18 @@ type ct struct {
 // FieldType defines the type of a field (acts like an enum)
 type FieldType int
 
-// TODO add new types, like date, blob, whatever
 // Field types
 
 // Create creates a database with it's structure
 func (d *ct) Create(tableName string, tableStruct *FieldDef) error {
 	d.tableName = tableName
 	d.tableStruct = tableStruct
-	err := d.filer.CreateDBFolderIfNotExists()
+	err := d.validate()
+	if err != nil {
+		return err
+	}
+
+	err = d.filer.CreateDBFolderIfNotExists()
 	if err != nil {
 		return err
 	}
@@ -55,6 +59,10 @@ func (d *ct) Create(tableName string, tableStruct *FieldDef) error {
 		return err
 	}
 
+	if err := d.createMemoFile(); err != nil {
+		return err
+	}
+
 	if err := d.createIndexes(); err != nil {
 		return err
 	}
@@ -62,21 +70,39 @@ func (d *ct) Create(tableName string, tableStruct *FieldDef) error {
 	return nil
 }
 
-func (d *ct) createIndexes() error {
+func (d *ct) validate() error {
 	for _, field := range d.tableStruct.Fields {
+		if field.isMemo() && len(field.Indexes) > 0 {
+			return fmt.Errorf("memo and blob field %s cannot be indexed", field.Name)
+		}
+
+		switch field.Overflow {
+		case "", OverflowError, OverflowTruncate, OverflowWarn:
+		default:
+			return fmt.Errorf("field %s has unknown overflow policy '%s'", field.Name, field.Overflow)
+		}
+	}
+
+	_, err := tableIndexes(d.tableStruct)
+
+	return err
+}
+
+func (d *ct) createIndexes() error {
+	indexes, err := tableIndexes(d.tableStruct)
+	if err != nil {
+		return err
+	}
 
-		intIndex := false
-		if field.Type == FtInt {
-			intIndex = true
+	for _, index := range indexes {
+		err := index.open()
+		if err != nil {
+			return err
 		}
 
-		if field.Indexes != nil {
-			for _, index := range field.Indexes {
-				_, err := btree.New(index.Name, field.Length, intIndex)
-				if err != nil {
-					return err
-				}
-			}
+		err = index.index.Close()
+		if err != nil {
+			return err
 		}
 	}
 
@@ -124,3 +150,17 @@ func (d *ct) createDataFile() error {
 	_, err := d.filer.CreateBlankFileIfNotExist(d.tableName + dataFileExt)
 	return err
 }
+
+func (d *ct) createMemoFile() error {
+	if !d.tableStruct.hasMemo() {
+		return nil
+	}
+
+	fileName := d.tableName + memoFileExt
+	created, err := d.filer.CreateBlankFileIfNotExist(fileName)
+	if err != nil || !created {
+		return err
+	}
+
+	return writeMemoHeader(d.filer, fileName)
+}
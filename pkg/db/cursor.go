@@ -0,0 +1,54 @@
+package localdb
+
+// Cursor creates a new cursor on the table at the position and with the index in use of the table. The cursor has
+// it's own position and index navigation, so more cursors can walk the table at the same time (reads only, the writes
+// must not run in parallel). It shares the files with the table, the manager methods work on it like on the table
+func (f *fetch) Cursor(c *CurrentTable) (*CurrentTable, error) {
+	cursor := &CurrentTable{
+		sharedTable:    c.sharedTable,
+		recordNo:       c.recordNo,
+		userIndexField: c.userIndexField,
+		userTableIndex: c.userTableIndex,
+		softSeek:       c.softSeek,
+		cursor:         true,
+	}
+
+	if c.userTableIndex == nil {
+		return cursor, nil
+	}
+
+	var err error
+	cursor.userIndex, err = cursor.orderedIndex(*c.userTableIndex)
+	if err != nil || cursor.userIndex == nil || cursor.Eof() {
+		return cursor, err
+	}
+
+	return cursor, f.syncIndex(cursor)
+}
+
+// syncIndex moves the index cursor to the record of the cursor, the key of the record is searched, then the record
+// among the records of the key. A record missing from the index (filtered out) leaves it on the nearest key
+func (f *fetch) syncIndex(c *CurrentTable) error {
+	record, _, eof, _, err := f.readRecord(c, c.recordNo)
+	if err != nil || eof {
+		return err
+	}
+
+	data, err := f.mapBufferToData(c, record)
+	if err != nil {
+		return err
+	}
+
+	key, err := f.inserter.key(*c.userTableIndex, data)
+	if err != nil {
+		return err
+	}
+
+	recNo, _, found, err := c.userIndex.Search(key)
+	for err == nil && found && recNo != c.recordNo {
+		recNo, _, eof, err = c.userIndex.Next()
+		found = !eof
+	}
+
+	return err
+}
@@ -0,0 +1,214 @@
+package localdb
+
+import (
+	filemanager "godb/pkg/file"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type cursorTestSuite struct {
+	suite.Suite
+	db Manager
+	ct *CurrentTable
+}
+
+func TestCursorRunner(t *testing.T) {
+	suite.Run(t, new(cursorTestSuite))
+}
+
+func (t *cursorTestSuite) SetupTest() {
+	err := os.RemoveAll(filemanager.DefaultFolder)
+	if err != nil {
+		panic("Cannot run test, the folder cannot be removed " + err.Error())
+	}
+
+	t.db = New()
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "name", Type: FtText, Length: 10, Indexes: []IndexDef{
+				{Name: "cursor_name"},
+				{Name: "cursor_name_bin", Type: IndexTypeBinaryTree},
+			}},
+			{Name: "qty", Type: FtInt},
+		},
+	}
+	tableName := "cursor_tests"
+	err = t.db.Create(tableName, tableStruct)
+	if err != nil {
+		panic("Cannot run test, Could not create database " + err.Error())
+	}
+
+	ct, err := t.db.Open(tableName)
+	if err != nil {
+		panic("Cannot open table " + err.Error())
+	}
+
+	t.ct = ct
+
+	for x, name := range []string{"dan", "amy", "carl", "bob", "amy"} {
+		_, err = t.db.Insert(t.ct, map[string]interface{}{"name": name, "qty": x})
+		if err != nil {
+			panic("Cannot insert test data " + err.Error())
+		}
+	}
+}
+
+func (t *cursorTestSuite) TearDownTest() {
+	t.ct.Close()
+	t.db = nil
+}
+
+// names walks the table from the current record to the end
+func (t *cursorTestSuite) names(c *CurrentTable) []string {
+	var names []string
+	for {
+		data, _, _, err := t.db.FetchCurrent(c)
+		t.Nil(err)
+		names = append(names, data["name"].(string))
+
+		eof, err := t.db.Next(c)
+		t.Nil(err)
+		if eof {
+			return names
+		}
+	}
+}
+
+func (t *cursorTestSuite) TestIndependentPositions() {
+	for _, indexName := range []string{"cursor_name", "cursor_name_bin"} {
+		err := t.db.Use(t.ct, indexName)
+		t.Nil(err)
+
+		err = t.db.First(t.ct)
+		t.Nil(err)
+
+		// the cursor starts on the record of the table, the second amy
+		_, err = t.db.Next(t.ct)
+		t.Nil(err)
+		t.Equal(int64(4), t.ct.CursorPos())
+
+		cursor, err := t.db.Cursor(t.ct)
+		t.Nil(err)
+		t.Equal(int64(4), cursor.CursorPos())
+
+		_, err = t.db.Next(t.ct)
+		t.Nil(err)
+		t.Equal(int64(3), t.ct.CursorPos())
+		t.Equal(int64(4), cursor.CursorPos())
+
+		t.Equal([]string{"amy", "bob", "carl", "dan"}, t.names(cursor), indexName)
+		t.Equal(int64(3), t.ct.CursorPos())
+		t.Equal([]string{"bob", "carl", "dan"}, t.names(t.ct), indexName)
+
+		t.Nil(cursor.Close())
+	}
+}
+
+func (t *cursorTestSuite) TestOwnIndexInUse() {
+	err := t.db.Use(t.ct, "cursor_name")
+	t.Nil(err)
+
+	cursor, err := t.db.Cursor(t.ct)
+	t.Nil(err)
+
+	err = t.db.Use(cursor, "")
+	t.Nil(err)
+
+	err = t.db.First(cursor)
+	t.Nil(err)
+	err = t.db.First(t.ct)
+	t.Nil(err)
+
+	t.Equal([]string{"dan", "amy", "carl", "bob", "amy"}, t.names(cursor))
+	t.Equal([]string{"amy", "amy", "bob", "carl", "dan"}, t.names(t.ct))
+}
+
+func (t *cursorTestSuite) TestConcurrentWalks() {
+	err := t.db.Use(t.ct, "cursor_name")
+	t.Nil(err)
+
+	const walkers = 8
+	results := make([][]string, walkers)
+	var wg sync.WaitGroup
+	for x := range walkers {
+		cursor, err := t.db.Cursor(t.ct)
+		t.Nil(err)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for range 20 {
+				var names []string
+				err := t.db.First(cursor)
+				if err != nil {
+					return
+				}
+
+				for eof := false; !eof; eof, err = t.db.Next(cursor) {
+					data, _, _, err := t.db.FetchCurrent(cursor)
+					if err != nil {
+						return
+					}
+					names = append(names, data["name"].(string))
+				}
+
+				results[x] = names
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, names := range results {
+		t.Equal([]string{"amy", "amy", "bob", "carl", "dan"}, names)
+	}
+}
+
+func (t *cursorTestSuite) TestCloseAndInserts() {
+	err := t.db.Use(t.ct, "cursor_name")
+	t.Nil(err)
+
+	cursor, err := t.db.Cursor(t.ct)
+	t.Nil(err)
+
+	_, err = t.db.Insert(t.ct, map[string]interface{}{"name": "abe", "qty": 5})
+	t.Nil(err)
+
+	// the cursor sees the records inserted through the table
+	found, err := t.db.Seek(cursor, "abe")
+	t.Nil(err)
+	t.True(found)
+	t.Equal(int64(5), cursor.CursorPos())
+
+	count, err := t.db.RecCount(cursor)
+	t.Nil(err)
+	t.Equal(int64(6), count)
+
+	// closing the cursor leaves the files of the table open
+	t.Nil(cursor.Close())
+
+	found, err = t.db.Seek(t.ct, "carl")
+	t.Nil(err)
+	t.True(found)
+
+	data, _, _, err := t.db.FetchCurrent(t.ct)
+	t.Nil(err)
+	t.Equal("carl", data["name"])
+}
+
+func (t *cursorTestSuite) TestUnorderedTable() {
+	err := t.db.Last(t.ct)
+	t.Nil(err)
+
+	cursor, err := t.db.Cursor(t.ct)
+	t.Nil(err)
+	t.Equal(int64(4), cursor.CursorPos())
+
+	err = t.db.First(cursor)
+	t.Nil(err)
+	t.Equal(int64(0), cursor.CursorPos())
+	t.Equal(int64(4), t.ct.CursorPos())
+}
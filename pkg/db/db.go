@@ -1,7 +1,10 @@
 // Package localdb is a local file database implementation, for built in database management
 package localdb
 
-import "fmt"
+import (
+	"fmt"
+	"iter"
+)
 
 // New creates a new database manager object
 func New() Manager {
@@ -10,6 +13,9 @@ func New() Manager {
 		inserter:     newInserter(),
 		fetcher:      newFetcher(),
 		deleter:      newDeleter(),
+		updater:      newUpdater(),
+		packer:       newPacker(),
+		zapper:       newZapper(),
 	}
 }
 
@@ -20,6 +26,7 @@ type Manager interface {
 	Struct(c *CurrentTable) *FieldDef
 	Close(c *CurrentTable) error
 	Insert(*CurrentTable, map[string]interface{}) (*CurrentTable, error)
+	Update(c *CurrentTable, recNo int64, data map[string]interface{}) error
 	RecCount(c *CurrentTable) (int64, error)
 	First(c *CurrentTable) error
 	Last(c *CurrentTable) error
@@ -28,11 +35,18 @@ type Manager interface {
 	Next(c *CurrentTable) (bool, error)
 	Prev(c *CurrentTable) (bool, error)
 	Locate(c *CurrentTable, fieldName string, value interface{}) (map[string]interface{}, error)
-	Seek(c *CurrentTable, value interface{}) error
+	Seek(c *CurrentTable, value interface{}) (bool, error)
+	Range(c *CurrentTable, from, to interface{}, opts RangeOpts) (iter.Seq2[map[string]interface{}, error], error)
+	SeekPrefix(c *CurrentTable, prefix string) (bool, error)
+	ScanPrefix(c *CurrentTable, prefix string, limit int) (iter.Seq2[map[string]interface{}, error], error)
 	Delete(c *CurrentTable, recNo int64) error
+	Recall(c *CurrentTable, recNo int64) error
+	IsDeleted(c *CurrentTable, recNo int64) (bool, error)
+	Pack(c *CurrentTable) (*PackStat, error)
+	Zap(c *CurrentTable) error
 	Use(c *CurrentTable, indexName string) error
+	Cursor(c *CurrentTable) (*CurrentTable, error)
 	// Add recNo
-	// Add update
 }
 
 type db struct {
@@ -40,6 +54,9 @@ type db struct {
 	inserter     inserter
 	fetcher      fetcher
 	deleter      deleter
+	updater      updater
+	packer       packer
+	zapper       zapper
 }
 
 // Create creates a database with it's structure
@@ -67,6 +84,11 @@ func (d *db) Insert(c *CurrentTable, data map[string]interface{}) (*CurrentTable
 	return d.inserter.Insert(c, data)
 }
 
+// Update rewrites the record in place and replaces it's changed keys in the indexes
+func (d *db) Update(c *CurrentTable, recNo int64, data map[string]interface{}) error {
+	return d.updater.Update(c, recNo, data)
+}
+
 // RecCount returns with the number of records in the table
 func (d *db) RecCount(c *CurrentTable) (int64, error) {
 	return c.recCount()
@@ -107,32 +129,81 @@ func (d *db) Locate(c *CurrentTable, fieldName string, value interface{}) (map[s
 	return d.fetcher.Locate(c, fieldName, value)
 }
 
-// Seek tries to set the index cursor to the closest element in the tree
-func (d *db) Seek(c *CurrentTable, value interface{}) error {
+// Seek moves the cursor to the key in the index in use and reports if it was found (dBase FOUND()), when not found the
+// cursor is on the end of file or with soft seek on the next key
+func (d *db) Seek(c *CurrentTable, value interface{}) (bool, error) {
 	return d.fetcher.Seek(c, value)
 }
 
+// Range iterates over the records of the index in use between the from and to keys, nil bounds are open
+func (d *db) Range(c *CurrentTable, from, to interface{}, opts RangeOpts) (iter.Seq2[map[string]interface{}, error], error) {
+	return d.fetcher.Range(c, from, to, opts)
+}
+
+// SeekPrefix moves the cursor to the first key of the text index in use starting with the prefix, reports if found
+func (d *db) SeekPrefix(c *CurrentTable, prefix string) (bool, error) {
+	return d.fetcher.SeekPrefix(c, prefix)
+}
+
+// ScanPrefix iterates over the records of the text index in use with keys starting with the prefix
+func (d *db) ScanPrefix(c *CurrentTable, prefix string, limit int) (iter.Seq2[map[string]interface{}, error], error) {
+	return d.fetcher.ScanPrefix(c, prefix, limit)
+}
+
 // Delete deletes / mark as deleted the record (index not used, record id needs to be provided)
 func (d *db) Delete(c *CurrentTable, recNo int64) error {
 	return d.deleter.Delete(c, recNo)
 }
 
+// Recall restores a record marked as deleted (dBase RECALL), adds it back to the indexes
+func (d *db) Recall(c *CurrentTable, recNo int64) error {
+	return d.deleter.Recall(c, recNo)
+}
+
+// IsDeleted reports if the record is marked as deleted
+func (d *db) IsDeleted(c *CurrentTable, recNo int64) (bool, error) {
+	return d.deleter.IsDeleted(c, recNo)
+}
+
+// Pack physically removes the deleted records, renumbers the remaining ones and rebuilds the indexes
+func (d *db) Pack(c *CurrentTable) (*PackStat, error) {
+	return d.packer.Pack(c)
+}
+
+// Zap removes all records of the table, keeps the table definition and empties the indexes
+func (d *db) Zap(c *CurrentTable) error {
+	return d.zapper.Zap(c)
+}
+
+// Cursor creates a cursor on the table, with it's own position and index navigation over the same files
+func (d *db) Cursor(c *CurrentTable) (*CurrentTable, error) {
+	return d.fetcher.Cursor(c)
+}
+
 // Use will set an index to be used for locate, seek, next, prior, first, last
 func (d *db) Use(c *CurrentTable, indexName string) error {
 	// Empty string resets using no index
 	if indexName == "" {
 		c.userIndex = nil
+		c.userIndexField = nil
+		c.userTableIndex = nil
 		return nil
 	}
 
-	for _, field := range c.fieldDef.Fields {
-		if field.Indexes != nil {
-			for _, index := range field.Indexes {
-				if index.Name == indexName {
-					c.userIndex = index.index
-					return nil
-				}
+	for x, index := range c.indexes {
+		if index.Name == indexName {
+			ordered, err := c.orderedIndex(index)
+			if err != nil {
+				return err
+			}
+
+			c.userIndex = ordered
+			c.userTableIndex = &c.indexes[x]
+			c.userIndexField = nil
+			if index.isFieldIndex() {
+				c.userIndexField = &index.fields[0]
 			}
+			return nil
 		}
 	}
 
@@ -1,21 +1,142 @@
 package localdb
 
-import filemanager "godb/pkg/file"
+import (
+	"fmt"
+	filemanager "godb/pkg/file"
+)
 
 func newDeleter() deleter {
-	return &del{filer: filemanager.New()}
+	filer := filemanager.New()
+	return &del{
+		filer:    filer,
+		inserter: &ins{filer: filer},
+		fetcher:  &fetch{filer: filer},
+	}
 }
 
 type deleter interface {
 	Delete(c *CurrentTable, recNo int64) error
+	Recall(c *CurrentTable, recNo int64) error
+	IsDeleted(c *CurrentTable, recNo int64) (bool, error)
 }
 
 type del struct {
-	filer filemanager.Filer
+	filer    filemanager.Filer
+	inserter *ins
+	fetcher  *fetch
 }
 
+// Delete marks the record deleted and removes it's keys from the indexes
 func (d *del) Delete(c *CurrentTable, recNo int64) error {
+	d.inserter.CurrentTable = c
+
+	record, _, eof, isDeleted, err := d.fetcher.readRecord(c, recNo)
+	if err != nil {
+		return err
+	}
+
+	if eof {
+		return fmt.Errorf("record %d does not exists, cannot delete it", recNo)
+	}
+
+	if isDeleted {
+		return nil
+	}
+
+	data, err := d.fetcher.mapBufferToData(c, record)
+	if err != nil {
+		return err
+	}
+
+	err = d.removeFromIndexes(c, data, recNo)
+	if err != nil {
+		return err
+	}
+
+	return d.setDeletedFlag(c, recNo, true)
+}
+
+// Recall clears the deleted flag of the record and adds it's keys back to the indexes
+func (d *del) Recall(c *CurrentTable, recNo int64) error {
+	d.inserter.CurrentTable = c
+
+	record, _, eof, isDeleted, err := d.fetcher.readRecord(c, recNo)
+	if err != nil {
+		return err
+	}
+
+	if eof {
+		return fmt.Errorf("record %d does not exists, cannot recall it", recNo)
+	}
+
+	if !isDeleted {
+		return nil
+	}
+
+	data, err := d.fetcher.mapBufferToData(c, record)
+	if err != nil {
+		return err
+	}
+
+	// A new record may have taken the unique keys since the delete
+	err = d.inserter.checkUnique(data)
+	if err != nil {
+		return err
+	}
+
+	err = d.setDeletedFlag(c, recNo, false)
+	if err != nil {
+		return err
+	}
+
+	return d.inserter.addToIndexIfIndexed(data, recNo)
+}
+
+// IsDeleted reports if the record is marked as deleted
+func (d *del) IsDeleted(c *CurrentTable, recNo int64) (bool, error) {
+	_, isDeleted, eof, err := d.filer.GetDatFilePointer(c.fileHandlers.rpt, recNo)
+	if err != nil {
+		return false, err
+	}
+
+	if eof {
+		return false, fmt.Errorf("record %d does not exists", recNo)
+	}
+
+	return isDeleted, nil
+}
+
+func (d *del) setDeletedFlag(c *CurrentTable, recNo int64, isDeleted bool) error {
 	ptrFilePointer := recNo*filemanager.PointerRecordLength + filemanager.Int64Length
+	flag := []byte{0}
+	if isDeleted {
+		flag[0] = 1
+	}
+
+	return d.filer.WriteBytes(c.fileHandlers.rpt, ptrFilePointer, flag)
+}
+
+func (d *del) removeFromIndexes(c *CurrentTable, data map[string]interface{}, recNo int64) error {
+	for _, index := range c.indexes {
+		included, err := index.includes(d.inserter, data)
+		if err != nil {
+			return err
+		}
+
+		if !included {
+			continue
+		}
+
+		key, err := d.inserter.key(index, data)
+		if err != nil {
+			return err
+		}
+
+		err = index.index.Delete(key, recNo)
+		if err != nil {
+			return err
+		}
+	}
 
-	return d.filer.WriteBytes(c.fileHandlers.rpt, ptrFilePointer, []byte{1})
+	return nil
 }
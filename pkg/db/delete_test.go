@@ -0,0 +1,178 @@
+package localdb
+
+import (
+	filemanager "godb/pkg/file"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type deleteTestSuite struct {
+	suite.Suite
+	db Manager
+	ct *CurrentTable
+}
+
+func TestDeleteRunner(t *testing.T) {
+	suite.Run(t, new(deleteTestSuite))
+}
+
+func (t *deleteTestSuite) SetupTest() {
+	err := os.RemoveAll(filemanager.DefaultFolder)
+	if err != nil {
+		panic("Cannot run test, the folder cannot be removed " + err.Error())
+	}
+
+	t.db = New()
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "field_1", Type: FtText, Length: 15, Indexes: []IndexDef{{Name: "del_field_1"}}},
+			{Name: "field_2", Type: FtInt},
+		},
+	}
+	tableName := "delete_tests"
+	err = t.db.Create(tableName, tableStruct)
+	if err != nil {
+		panic("Cannot run test, Could not create database " + err.Error())
+	}
+
+	ct, err := t.db.Open(tableName)
+	if err != nil {
+		panic("Cannot open table " + err.Error())
+	}
+
+	t.ct = ct
+
+	for i := 0; i < 10; i++ {
+		_, err = t.db.Insert(t.ct, map[string]interface{}{
+			"field_1": "data " + strconv.Itoa(i),
+			"field_2": int64(i),
+		})
+		if err != nil {
+			panic("Cannot insert test data " + err.Error())
+		}
+	}
+}
+
+func (t *deleteTestSuite) TearDownTest() {
+	t.ct.Close()
+	t.db = nil
+}
+
+func (t *deleteTestSuite) TestDeleteRemovesIndexEntry() {
+	for _, recNo := range []int64{0, 4, 9} {
+		err := t.db.Delete(t.ct, recNo)
+		t.Nil(err)
+	}
+
+	err := t.db.Use(t.ct, "del_field_1")
+	t.Nil(err)
+
+	_, err = t.db.Locate(t.ct, "field_1", "data 4")
+	t.ErrorIs(err, errNotFound)
+
+	err = t.db.First(t.ct)
+	t.Nil(err)
+	t.Equal([]int64{1, 2, 3, 5, 6, 7, 8}, t.collect(true))
+
+	err = t.db.Last(t.ct)
+	t.Nil(err)
+	t.Equal([]int64{8, 7, 6, 5, 3, 2, 1}, t.collect(false))
+}
+
+func (t *deleteTestSuite) TestNaturalOrderSkipsDeleted() {
+	for _, recNo := range []int64{0, 4, 5, 9} {
+		err := t.db.Delete(t.ct, recNo)
+		t.Nil(err)
+	}
+
+	err := t.db.First(t.ct)
+	t.Nil(err)
+	t.Equal([]int64{1, 2, 3, 6, 7, 8}, t.collect(true))
+
+	err = t.db.Last(t.ct)
+	t.Nil(err)
+	t.Equal([]int64{8, 7, 6, 3, 2, 1}, t.collect(false))
+
+	res, err := t.db.Locate(t.ct, "field_2", int64(7))
+	t.Nil(err)
+	t.Equal("data 7", res["field_1"])
+
+	_, err = t.db.Locate(t.ct, "field_2", int64(5))
+	t.ErrorIs(err, errNotFound)
+}
+
+func (t *deleteTestSuite) TestDeleteTwiceAndOutOfRange() {
+	err := t.db.Delete(t.ct, 3)
+	t.Nil(err)
+
+	err = t.db.Delete(t.ct, 3)
+	t.Nil(err)
+
+	err = t.db.Delete(t.ct, 30)
+	t.Error(err)
+}
+
+func (t *deleteTestSuite) TestRecallRestoresRecordAndIndex() {
+	err := t.db.Delete(t.ct, 4)
+	t.Nil(err)
+
+	isDeleted, err := t.db.IsDeleted(t.ct, 4)
+	t.Nil(err)
+	t.True(isDeleted)
+
+	err = t.db.Recall(t.ct, 4)
+	t.Nil(err)
+
+	isDeleted, err = t.db.IsDeleted(t.ct, 4)
+	t.Nil(err)
+	t.False(isDeleted)
+
+	res, _, isDeleted, err := t.db.Fetch(t.ct, 4)
+	t.Nil(err)
+	t.False(isDeleted)
+	t.Equal("data 4", res["field_1"])
+
+	err = t.db.Use(t.ct, "del_field_1")
+	t.Nil(err)
+
+	res, err = t.db.Locate(t.ct, "field_1", "data 4")
+	t.Nil(err)
+	t.Equal(int64(4), res["_recNo"])
+
+	// Recalling a non deleted record does not duplicate the index entry
+	err = t.db.Recall(t.ct, 4)
+	t.Nil(err)
+
+	err = t.db.First(t.ct)
+	t.Nil(err)
+	t.Len(t.collect(true), 10)
+
+	err = t.db.Recall(t.ct, 30)
+	t.Error(err)
+
+	_, err = t.db.IsDeleted(t.ct, 30)
+	t.Error(err)
+}
+
+func (t *deleteTestSuite) collect(moveDown bool) []int64 {
+	result := []int64{t.ct.CursorPos()}
+	for {
+		var eof bool
+		var err error
+		if moveDown {
+			eof, err = t.db.Next(t.ct)
+		} else {
+			eof, err = t.db.Prev(t.ct)
+		}
+		t.Nil(err)
+		if eof || err != nil {
+			break
+		}
+		result = append(result, t.ct.CursorPos())
+	}
+
+	return result
+}
@@ -0,0 +1,148 @@
+package localdb
+
+import (
+	filemanager "godb/pkg/file"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type expressionTestSuite struct {
+	suite.Suite
+	db Manager
+	ct *CurrentTable
+}
+
+func TestExpressionRunner(t *testing.T) {
+	suite.Run(t, new(expressionTestSuite))
+}
+
+func (t *expressionTestSuite) SetupTest() {
+	err := os.RemoveAll(filemanager.DefaultFolder)
+	if err != nil {
+		panic("Cannot run test, the folder cannot be removed " + err.Error())
+	}
+
+	t.db = New()
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "name", Type: FtText, Length: 10},
+			{Name: "city", Type: FtText, Length: 10, Nullable: true},
+			{Name: "born", Type: FtDate},
+			{Name: "qty", Type: FtInt},
+		},
+		Indexes: []IndexDef{
+			{Name: "expr_name_city", Expression: "UPPER(name)+city"},
+			{Name: "expr_born_qty", Expression: "DTOS(born)+STR(qty, 5)", Unique: true},
+		},
+	}
+	tableName := "expression_tests"
+	err = t.db.Create(tableName, tableStruct)
+	if err != nil {
+		panic("Cannot run test, Could not create database " + err.Error())
+	}
+
+	ct, err := t.db.Open(tableName)
+	if err != nil {
+		panic("Cannot open table " + err.Error())
+	}
+
+	t.ct = ct
+
+	rows := []map[string]interface{}{
+		{"name": "john", "city": "london", "born": "1990-05-01", "qty": 3},
+		{"name": "Anna", "city": "rome", "born": "1985-01-01", "qty": 1},
+		{"name": "JOHNNY", "city": "berlin", "born": "1990-05-01", "qty": 20},
+		{"name": "John", "city": "berlin", "born": "2000-02-02", "qty": 1},
+		{"name": "bob", "city": nil, "born": "1970-12-31", "qty": 5},
+	}
+	for _, row := range rows {
+		_, err = t.db.Insert(t.ct, row)
+		if err != nil {
+			panic("Cannot insert test data " + err.Error())
+		}
+	}
+}
+
+func (t *expressionTestSuite) TearDownTest() {
+	t.ct.Close()
+	t.db = nil
+}
+
+func (t *expressionTestSuite) walk() []int64 {
+	recNos := make([]int64, 0)
+	err := t.db.First(t.ct)
+	t.Nil(err)
+
+	for {
+		recNos = append(recNos, t.ct.CursorPos())
+		eof, err := t.db.Next(t.ct)
+		t.Nil(err)
+		if eof {
+			return recNos
+		}
+	}
+}
+
+func (t *expressionTestSuite) TestOrder() {
+	// The name is padded to the field length, "JOHN" sorts before "JOHNNY" whatever the city is
+	err := t.db.Use(t.ct, "expr_name_city")
+	t.Nil(err)
+	t.Equal([]int64{1, 4, 3, 0, 2}, t.walk())
+
+	err = t.db.Use(t.ct, "expr_born_qty")
+	t.Nil(err)
+	t.Equal([]int64{4, 1, 0, 2, 3}, t.walk())
+}
+
+func (t *expressionTestSuite) TestSeekAppliesExpression() {
+	err := t.db.Use(t.ct, "expr_name_city")
+	t.Nil(err)
+
+	_, err = t.db.Seek(t.ct, "john")
+	t.Nil(err)
+	t.Equal(int64(3), t.ct.CursorPos())
+
+	_, err = t.db.Seek(t.ct, map[string]interface{}{"name": "jOhN", "city": "london"})
+	t.Nil(err)
+	t.Equal(int64(0), t.ct.CursorPos())
+
+	_, err = t.db.Seek(t.ct, "johnny")
+	t.Nil(err)
+	t.Equal(int64(2), t.ct.CursorPos())
+
+	err = t.db.Use(t.ct, "expr_born_qty")
+	t.Nil(err)
+
+	_, err = t.db.Seek(t.ct, map[string]interface{}{"born": "1990-05-01", "qty": 20})
+	t.Nil(err)
+	t.Equal(int64(2), t.ct.CursorPos())
+}
+
+func (t *expressionTestSuite) TestUpdateDeleteAndUnique() {
+	_, err := t.db.Insert(t.ct, map[string]interface{}{"name": "x", "born": "1990-05-01", "qty": 3})
+	t.ErrorIs(err, ErrDuplicateKey)
+	t.EqualError(err, "duplicate key '19900501    3' in unique index expr_born_qty")
+
+	err = t.db.Update(t.ct, 1, map[string]interface{}{"name": "zed"})
+	t.Nil(err)
+
+	err = t.db.Delete(t.ct, 3)
+	t.Nil(err)
+
+	err = t.db.Use(t.ct, "expr_name_city")
+	t.Nil(err)
+	t.Equal([]int64{4, 0, 2, 1}, t.walk())
+}
+
+func (t *expressionTestSuite) TestInvalidDefinition() {
+	for _, expression := range []string{"UPPER(missing)", "UPPER(qty)", "UPPER(name", "qty", "SUBSTR(name, 1, 0)"} {
+		tableStruct := &FieldDef{
+			Fields:  []Field{{Name: "name", Type: FtText, Length: 10}, {Name: "qty", Type: FtInt}},
+			Indexes: []IndexDef{{Name: "expr_invalid", Expression: expression}},
+		}
+		err := t.db.Create("expression_invalid", tableStruct)
+		t.Error(err, expression)
+	}
+}
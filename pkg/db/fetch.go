@@ -1,17 +1,27 @@
 package localdb
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"godb/pkg/btree"
 	filemanager "godb/pkg/file"
+	"iter"
+	"math"
+	"slices"
 	"strings"
+	"time"
 )
 
 var errNotFound = errors.New("not found")
 
 func newFetcher() fetcher {
-	return &fetch{filer: filemanager.New()}
+	filer := filemanager.New()
+	return &fetch{
+		filer:    filer,
+		inserter: &ins{filer: filer},
+	}
 }
 
 type fetcher interface {
@@ -22,17 +32,31 @@ type fetcher interface {
 	Next(c *CurrentTable) (bool, error)
 	Prev(c *CurrentTable) (bool, error)
 	Locate(c *CurrentTable, fieldName string, value interface{}) (map[string]interface{}, error)
-	Seek(c *CurrentTable, value interface{}) error
+	Seek(c *CurrentTable, value interface{}) (bool, error)
+	Range(c *CurrentTable, from, to interface{}, opts RangeOpts) (iter.Seq2[map[string]interface{}, error], error)
+	SeekPrefix(c *CurrentTable, prefix string) (bool, error)
+	ScanPrefix(c *CurrentTable, prefix string, limit int) (iter.Seq2[map[string]interface{}, error], error)
+	Cursor(c *CurrentTable) (*CurrentTable, error)
 }
 
 type fetch struct {
-	filer        filemanager.Filer
-	CurrentTable *CurrentTable
+	filer    filemanager.Filer
+	inserter *ins
 }
 
 func (f *fetch) First(c *CurrentTable) error {
+	err := unordered(c)
+	if err != nil {
+		return err
+	}
+
+	return f.first(c)
+}
+
+// first moves to the first record of the btree index in use, or of the table
+func (f *fetch) first(c *CurrentTable) error {
 	if c.userIndex != nil {
-		index := *c.userIndex
+		index := c.userIndex
 		ptr, _, err := index.First()
 		if err != nil {
 			return err
@@ -43,23 +67,34 @@ func (f *fetch) First(c *CurrentTable) error {
 		c.recordNo = 0
 	}
 
-	_, _, isDeleted, err := f.Fetch(c, c.recordNo)
+	_, _, _, isDeleted, err := f.readRecord(c, c.recordNo)
 	if err != nil {
 		return err
 	}
+
 	if isDeleted {
-		_, err := f.Next(c)
+		_, err := f.moveCursor(c, true)
 		if err != nil {
 			return err
 		}
-
 	}
+
 	return nil
 }
 
 func (f *fetch) Last(c *CurrentTable) error {
+	err := unordered(c)
+	if err != nil {
+		return err
+	}
+
+	return f.last(c)
+}
+
+// last moves to the last record of the btree index in use, or of the table
+func (f *fetch) last(c *CurrentTable) error {
 	if c.userIndex != nil {
-		index := *c.userIndex
+		index := c.userIndex
 
 		ptr, _, err := index.Last()
 		if err != nil {
@@ -71,17 +106,16 @@ func (f *fetch) Last(c *CurrentTable) error {
 		c.recordNo = c.CursorCount() - 1
 	}
 
-	_, _, isDeleted, err := f.Fetch(c, c.recordNo)
+	_, _, _, isDeleted, err := f.readRecord(c, c.recordNo)
 	if err != nil {
 		return err
 	}
 
 	if isDeleted {
-		_, err := f.Prev(c)
+		_, err := f.moveCursor(c, false)
 		if err != nil {
 			return err
 		}
-		return nil
 	}
 
 	return nil
@@ -92,8 +126,7 @@ func (f *fetch) FetchCurrent(c *CurrentTable) (map[string]interface{}, bool, boo
 }
 
 func (f *fetch) Fetch(c *CurrentTable, recNo int64) (map[string]interface{}, bool, bool, error) {
-	f.CurrentTable = c
-	datFilePointer, isDeleted, eof, err := f.filer.GetDatFilePointer(c.fileHandlers.rpt, recNo)
+	record, _, eof, isDeleted, err := f.readRecord(c, recNo)
 	if err != nil {
 		return nil, false, false, err
 	}
@@ -108,35 +141,112 @@ func (f *fetch) Fetch(c *CurrentTable, recNo int64) (map[string]interface{}, boo
 		return nil, false, true, nil
 	}
 
-	record, eof, err := f.filer.ReadBytes(c.fileHandlers.dat, datFilePointer, c.recordSize)
+	result, err := f.mapBufferToData(c, record)
 	if err != nil {
 		return nil, false, false, err
 	}
+	result["_recNo"] = recNo
+
+	return result, false, false, nil
+}
+
+// readRecord reads the raw record buffer (deleted ones too) without moving the cursor, returns the buffer, it's data file pointer, eof and deleted flags
+func (f *fetch) readRecord(c *CurrentTable, recNo int64) ([]byte, int64, bool, bool, error) {
+	if recNo < 0 {
+		return nil, 0, true, false, nil
+	}
+
+	datFilePointer, isDeleted, eof, err := f.filer.GetDatFilePointer(c.fileHandlers.rpt, recNo)
+	if err != nil {
+		return nil, 0, false, false, err
+	}
 
 	if eof {
-		return nil, true, false, nil
+		return nil, 0, true, false, nil
 	}
 
-	c.recordNo = recNo
-	result, err := f.mapBufferToData(record)
-	result["_recNo"] = recNo
+	record, eof, err := f.filer.ReadBytes(c.fileHandlers.dat, datFilePointer, c.recordSize)
+	if err != nil {
+		return nil, 0, false, false, err
+	}
 
-	return result, false, false, err
+	if eof {
+		return nil, 0, true, false, nil
+	}
+
+	return record, datFilePointer, false, isDeleted, nil
 }
 
 func (f *fetch) Next(c *CurrentTable) (bool, error) {
+	err := unordered(c)
+	if err != nil {
+		return false, err
+	}
+
+	if c.Eof() {
+		return true, nil
+	}
+
 	return f.moveCursor(c, true)
 }
 
 func (f *fetch) Prev(c *CurrentTable) (bool, error) {
+	err := unordered(c)
+	if err != nil {
+		return false, err
+	}
+
+	if c.Eof() && c.recordCount > 0 {
+		// from the end of file the previous record is the last one, like SKIP -1 in dBase
+		return false, f.last(c)
+	}
+
 	return f.moveCursor(c, false)
 }
 
+// unordered fails if the index in use has no order to navigate or seek on, e.g. a hash index
+func unordered(c *CurrentTable) error {
+	if c.userTableIndex != nil && c.userIndex == nil {
+		return fmt.Errorf("index %s is a %s index, it has no order for seek and navigation", c.userTableIndex.Name, c.userTableIndex.Type)
+	}
+
+	return nil
+}
+
+// moveCursor moves the cursor until it finds a non deleted record, on eof / bof the cursor stays on the last visited one
 func (f *fetch) moveCursor(c *CurrentTable, moveDown bool) (bool, error) {
-	f.CurrentTable = c
+	startRecordNo := c.recordNo
 
+	for {
+		eof, err := f.step(c, moveDown)
+		if err != nil {
+			return false, err
+		}
+
+		if eof {
+			c.recordNo = startRecordNo
+			return true, nil
+		}
+
+		_, _, eof, isDeleted, err := f.readRecord(c, c.recordNo)
+		if err != nil {
+			return false, err
+		}
+
+		if eof {
+			c.recordNo = startRecordNo
+			return true, nil
+		}
+
+		if !isDeleted {
+			return false, nil
+		}
+	}
+}
+
+func (f *fetch) step(c *CurrentTable, moveDown bool) (bool, error) {
 	if c.userIndex != nil {
-		index := *c.userIndex
+		index := c.userIndex
 
 		var ptr int64
 		var eof bool
@@ -164,12 +274,10 @@ func (f *fetch) moveCursor(c *CurrentTable, moveDown bool) (bool, error) {
 	}
 
 	if !moveDown && c.recordNo == -1 {
-		c.recordNo = 0
 		return true, nil
 	}
 
 	if moveDown && c.recordNo >= c.recordCount {
-		c.recordNo = c.recordCount - 1
 		return true, nil
 	}
 
@@ -177,11 +285,14 @@ func (f *fetch) moveCursor(c *CurrentTable, moveDown bool) (bool, error) {
 }
 
 func (f *fetch) Locate(c *CurrentTable, fieldName string, value interface{}) (map[string]interface{}, error) {
-	if c.userIndex != nil {
-		index := *c.userIndex
-		// TODO it works only with sting for now, extract this logic and implement for each type
-		if v, ok := value.(string); ok {
-			ptr, _, found, err := index.Search([]byte(v))
+	if field, ok := c.field(fieldName); ok && field.Type == FtBlob {
+		return nil, fmt.Errorf("locate is not supported on blob field %s", fieldName)
+	}
+
+	if c.userIndex != nil && c.userIndexField != nil && c.userIndexField.Name == fieldName {
+		index := c.userIndex
+		if key, ok := f.seekKey(c, value); ok {
+			ptr, _, found, err := index.Search(key)
 			if err != nil {
 				return nil, err
 			}
@@ -194,34 +305,40 @@ func (f *fetch) Locate(c *CurrentTable, fieldName string, value interface{}) (ma
 				return nil, err
 			}
 
-			if eof {
+			if eof || isDeleted {
 				return nil, errNotFound
 			}
 
-			if isDeleted {
-				return val, fmt.Errorf("record is deleted")
-			}
-
 			return val, nil
 		}
 	}
 
-	result, eof, _, err := f.Fetch(c, int64(0))
-	if err != nil {
-		return nil, err
+	if index, lookup, ok := lookupIndex(c, fieldName, value); ok {
+		return f.locateLookup(c, index, lookup, value)
 	}
 
-	if eof {
-		return nil, errNotFound
+	err := f.first(c)
+	if err != nil {
+		return nil, err
 	}
 
 	for {
-		if val, ok := result[fieldName]; ok {
-			if val == value {
+		result, eof, isDeleted, err := f.FetchCurrent(c)
+		if err != nil {
+			return nil, err
+		}
+
+		if eof {
+			break
+		}
+
+		if val, ok := result[fieldName]; ok && !isDeleted {
+			if f.sameValue(c, fieldName, val, value) {
 				return result, nil
 			}
 		}
-		eof, err = f.Next(c)
+
+		eof, err = f.moveCursor(c, true)
 		if err != nil {
 			return nil, err
 		}
@@ -233,34 +350,245 @@ func (f *fetch) Locate(c *CurrentTable, fieldName string, value interface{}) (ma
 	return nil, errNotFound
 }
 
-// Seek tries to set the index cursor to the closest element in the tree
-func (f *fetch) Seek(c *CurrentTable, value interface{}) error {
-	if c.userIndex == nil {
-		return fmt.Errorf("seek only works if index is is use")
+// sameValue compares the stored value of the field with the searched one in the file format of the field, so e.g. an
+// int matches an int64 value
+func (f *fetch) sameValue(c *CurrentTable, fieldName string, stored, value interface{}) bool {
+	if stored == nil || value == nil {
+		return stored == value
 	}
 
-	index := *c.userIndex
-	// TODO it works only with sting for now, extract this logic and implement for each type
-	if v, ok := value.(string); ok {
-		recNo, _, _, err := index.Search([]byte(v))
+	field, ok := c.field(fieldName)
+	if !ok || field.isMemo() {
+		return stored == value
+	}
+
+	buf1, err := f.inserter.convertToFileData(*field, stored)
+	if err != nil {
+		return false
+	}
+
+	buf2, err := f.inserter.convertToFileData(*field, value)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(buf1, buf2)
+}
+
+// lookupIndex finds a lookup (e.g. hash) index of the field holding every record with the value, it does not have to
+// be in use
+func lookupIndex(c *CurrentTable, fieldName string, value interface{}) (tableIndex, LookupIndex, bool) {
+	for _, index := range c.indexes {
+		if !index.isFieldIndex() || index.fields[0].Name != fieldName || index.filter != nil {
+			continue
+		}
+
+		if value == nil && index.SkipNulls {
+			continue
+		}
+
+		if lookup, ok := index.index.(LookupIndex); ok {
+			return index, lookup, true
+		}
+	}
+
+	return tableIndex{}, nil, false
+}
+
+// locateLookup fetches the record with the lowest record number of the value from the lookup index
+func (f *fetch) locateLookup(c *CurrentTable, index tableIndex, lookup LookupIndex, value interface{}) (map[string]interface{}, error) {
+	key, err := f.inserter.indexKey(index.fields[0], value)
+	if err != nil {
+		return nil, errNotFound
+	}
+
+	recNos, err := lookup.Search(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(recNos) == 0 {
+		return nil, errNotFound
+	}
+
+	slices.Sort(recNos)
+	for _, recNo := range recNos {
+		val, eof, isDeleted, err := f.Fetch(c, recNo)
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		if !eof && !isDeleted {
+			return val, nil
 		}
+	}
+
+	return nil, errNotFound
+}
+
+// Seek moves the cursor to the record of the key and reports if it was found. Texts and the first fields of compound
+// keys are found by their beginning like in dBase. When not found the cursor goes to the end of file, or with soft
+// seek to the next key in the order of the index if there is one
+func (f *fetch) Seek(c *CurrentTable, value interface{}) (bool, error) {
+	err := unordered(c)
+	if err != nil {
+		return false, err
+	}
+
+	if c.userIndex == nil {
+		return false, fmt.Errorf("seek only works if index is is use")
+	}
+
+	key, ok := f.seekKey(c, value)
+	if !ok {
+		return false, fmt.Errorf("value %v is not a key of index %s", value, c.userTableIndex.Name)
+	}
+
+	recNo, landed, found, err := c.userIndex.Search(key)
+	if err != nil {
+		return false, err
+	}
+
+	length := matchLength(c, value, key)
+	if !found && landed != nil && length > 0 {
+		found = len(*landed) >= length && bytes.Equal((*landed)[:length], key[:length])
+	}
+
+	return seekResult(c, recNo, landed, key, found), nil
+}
+
+// seekResult places the cursor after the search of the key, on the found record, on the next key with soft seek or
+// on the end of file
+func seekResult(c *CurrentTable, recNo int64, landed *[]byte, key []byte, found bool) bool {
+	if found {
 		c.recordNo = recNo
+		return true
+	}
 
-		return nil
+	if c.softSeek && landed != nil && btree.Compare(*landed, key, c.userTableIndex.keyOptions()) > 0 {
+		c.recordNo = recNo
+		return false
 	}
 
-	return fmt.Errorf("Seek not yet implemented for the requested field type")
+	c.recordNo = c.recordCount
+
+	return false
 }
 
-func (f *fetch) mapBufferToData(data []byte) (map[string]interface{}, error) {
+// matchLength is the length of the beginning of the seek key a key has to match to be found, the texts and the given
+// fields of a compound key. 0 means an exact match
+func matchLength(c *CurrentTable, value interface{}, key []byte) int {
+	index := c.userTableIndex
+	switch {
+	case index.expression != nil:
+		return len(key)
+	case index.compound:
+		values, ok := value.([]interface{})
+		if !ok {
+			values = []interface{}{value}
+		}
+
+		length := 0
+		for _, field := range index.fields[:len(values)] {
+			length += sortableKeySize(field)
+		}
+
+		return length
+	case index.fields[0].Type == FtText:
+		return len(key)
+	}
+
+	return 0
+}
+
+// seekKey converts the value to a key of the index in use, reports false if it is not possible for the field type.
+// Compound indexes accept the values of the first fields ([]interface{}) or the value of the first field as key prefix.
+// Expression indexes apply the expression to the field values (map) or to the value of the first field of the expression
+func (f *fetch) seekKey(c *CurrentTable, value interface{}) ([]byte, bool) {
+	if c.userTableIndex.expression != nil {
+		return f.expressionSeekKey(*c.userTableIndex, value)
+	}
+
+	if c.userTableIndex.compound {
+		values, ok := value.([]interface{})
+		if !ok {
+			values = []interface{}{value}
+		}
+
+		key, err := f.inserter.compoundKey(c.userTableIndex.fields, values)
+		return key, err == nil
+	}
+
+	field := c.userIndexField
+	if !field.Nullable {
+		return f.valueKey(field, value)
+	}
+
+	if value == nil {
+		return []byte{btree.NullFlag}, true
+	}
+
+	key, ok := f.valueKey(field, value)
+	if !ok {
+		return nil, false
+	}
+
+	return append([]byte{btree.ValueFlag}, key...), true
+}
+
+// expressionSeekKey evaluates the expression on the seek value, when fields are missing the trailing spaces are removed
+// so the key is a prefix of the stored keys
+func (f *fetch) expressionSeekKey(index tableIndex, value interface{}) ([]byte, bool) {
+	data, ok := value.(map[string]interface{})
+	if !ok {
+		data = map[string]interface{}{index.fields[0].Name: value}
+	}
+
+	key, err := index.expressionKey(f.inserter, data)
+	if err != nil {
+		return nil, false
+	}
+
+	for _, field := range index.fields {
+		if _, ok := data[field.Name]; !ok {
+			return bytes.TrimRight(key, " "), true
+		}
+	}
+
+	return key, true
+}
+
+// valueKey converts the value to the key of the field by the field type. The text is not padded, the index pads it
+// with zeros, so it works as a prefix too
+func (f *fetch) valueKey(field *Field, value interface{}) ([]byte, bool) {
+	if field.Type == FtText {
+		v, ok := value.(string)
+		return []byte(v), ok
+	}
+
+	key, err := f.inserter.convertToFileData(*field, value)
+
+	return key, err == nil
+}
+
+func (f *fetch) mapBufferToData(c *CurrentTable, data []byte) (map[string]interface{}, error) {
 	mappedResult := make(map[string]interface{}, 0)
-	index := 0
+	index := c.fieldDef.nullBitmapSize()
 	str := ""
 	var integer int64
+	var float float64
+
+	for x, field := range c.fieldDef.Fields {
+		if field.Nullable && isNull(data, x) {
+			size, err := field.size()
+			if err != nil {
+				return nil, err
+			}
+			index += size
+			mappedResult[field.Name] = nil
+			continue
+		}
 
-	for _, field := range f.CurrentTable.fieldDef.Fields {
 		switch field.Type {
 		case FtText:
 			index, str = f.copyBuffToStr(data, index, field.Length)
@@ -275,6 +603,27 @@ func (f *fetch) mapBufferToData(data []byte) (map[string]interface{}, error) {
 		case FtInt:
 			index, integer = f.copyBuffToInt64(data, index)
 			mappedResult[field.Name] = integer
+		case FtReal:
+			index, float = f.copyBuffToFloat64(data, index)
+			mappedResult[field.Name] = float
+		case FtDate:
+			index, integer = f.copyBuffToInt64(data, index)
+			mappedResult[field.Name] = time.Unix(integer, 0).UTC()
+		case FtDateTime:
+			index, integer = f.copyBuffToInt64(data, index)
+			mappedResult[field.Name] = time.UnixMicro(integer).UTC()
+		case FtMemo, FtBlob:
+			index, integer = f.copyBuffToInt64(data, index)
+			buf, err := c.memo().read(integer)
+			if err != nil {
+				return nil, err
+			}
+
+			if field.Type == FtMemo {
+				mappedResult[field.Name] = string(buf)
+			} else {
+				mappedResult[field.Name] = buf
+			}
 		default:
 			return nil, fmt.Errorf("field type not implemented in mapBufferToData %d", field.Type)
 		}
@@ -307,3 +656,9 @@ func (f *fetch) copyBuffToInt64(buf []byte, index int) (int, int64) {
 
 	return index + filemanager.Int64Length, int64(binary.LittleEndian.Uint64(int64Buf))
 }
+
+func (f *fetch) copyBuffToFloat64(buf []byte, index int) (int, float64) {
+	index, bits := f.copyBuffToInt64(buf, index)
+
+	return index, math.Float64frombits(uint64(bits))
+}
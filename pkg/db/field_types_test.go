@@ -0,0 +1,304 @@
+package localdb
+
+import (
+	filemanager "godb/pkg/file"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type fieldTypesTestSuite struct {
+	suite.Suite
+	db Manager
+	ct *CurrentTable
+}
+
+func TestFieldTypesRunner(t *testing.T) {
+	suite.Run(t, new(fieldTypesTestSuite))
+}
+
+func (t *fieldTypesTestSuite) SetupTest() {
+	err := os.RemoveAll(filemanager.DefaultFolder)
+	if err != nil {
+		panic("Cannot run test, the folder cannot be removed " + err.Error())
+	}
+
+	t.db = New()
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "name", Type: FtText, Length: 10},
+			{Name: "price", Type: FtReal, Indexes: []IndexDef{{Name: "types_price"}}},
+		},
+	}
+	tableName := "field_types_tests"
+	err = t.db.Create(tableName, tableStruct)
+	if err != nil {
+		panic("Cannot run test, Could not create database " + err.Error())
+	}
+
+	ct, err := t.db.Open(tableName)
+	if err != nil {
+		panic("Cannot open table " + err.Error())
+	}
+
+	t.ct = ct
+}
+
+func (t *fieldTypesTestSuite) TearDownTest() {
+	t.ct.Close()
+	t.db = nil
+}
+
+func (t *fieldTypesTestSuite) TestRealField() {
+	values := []interface{}{2.25, -10.5, int64(0), math.NaN(), 1e10, -1, float32(0.5)}
+	for _, value := range values {
+		_, err := t.db.Insert(t.ct, map[string]interface{}{"name": "item", "price": value})
+		t.Nil(err)
+	}
+
+	_, err := t.db.Insert(t.ct, map[string]interface{}{"name": "item", "price": "1.5"})
+	t.Error(err)
+
+	res, _, _, err := t.db.Fetch(t.ct, 1)
+	t.Nil(err)
+	t.Equal(-10.5, res["price"])
+
+	res, _, _, err = t.db.Fetch(t.ct, 5)
+	t.Nil(err)
+	t.Equal(float64(-1), res["price"])
+
+	err = t.db.Use(t.ct, "types_price")
+	t.Nil(err)
+
+	// NaN sorts first, then numbers in ascending order
+	err = t.db.First(t.ct)
+	t.Nil(err)
+	expected := []int64{3, 1, 5, 2, 6, 0, 4}
+	for i, recNo := range expected {
+		t.Equal(recNo, t.ct.CursorPos())
+		eof, err := t.db.Next(t.ct)
+		t.Nil(err)
+		t.Equal(i == len(expected)-1, eof)
+	}
+
+	_, err = t.db.Seek(t.ct, -10.5)
+	t.Nil(err)
+	t.Equal(int64(1), t.ct.CursorPos())
+
+	res, err = t.db.Locate(t.ct, "price", 1e10)
+	t.Nil(err)
+	t.Equal(int64(4), res["_recNo"])
+
+	res, err = t.db.Locate(t.ct, "price", math.NaN())
+	t.Nil(err)
+	t.Equal(int64(3), res["_recNo"])
+
+	_, err = t.db.Locate(t.ct, "price", 3.5)
+	t.ErrorIs(err, errNotFound)
+}
+
+func (t *fieldTypesTestSuite) TestDateFields() {
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "born", Type: FtDate, Indexes: []IndexDef{{Name: "types_born"}}},
+			{Name: "seen", Type: FtDateTime},
+		},
+	}
+	err := t.db.Create("date_types_tests", tableStruct)
+	t.Nil(err)
+
+	ct, err := t.db.Open("date_types_tests")
+	t.Nil(err)
+	defer ct.Close()
+
+	seen := time.Date(2024, 3, 5, 23, 15, 30, 123456000, time.FixedZone("CET", 3600))
+	rows := []map[string]interface{}{
+		{"born": "1985-07-21", "seen": seen},
+		{"born": time.Date(1969, 12, 31, 22, 0, 0, 0, time.UTC), "seen": "2024-03-05T10:00:00Z"},
+		{"born": "2001-01-02 03:04:05", "seen": "2024-03-05 10:00:00"},
+		{"born": "1985-07-20T23:59:59+02:00", "seen": "2024-03-05T10:00:00.5+01:00"},
+	}
+	for _, row := range rows {
+		_, err := t.db.Insert(ct, row)
+		t.Nil(err)
+	}
+
+	_, err = t.db.Insert(ct, map[string]interface{}{"born": "21/07/1985", "seen": seen})
+	t.Error(err)
+
+	_, err = t.db.Insert(ct, map[string]interface{}{"born": int64(0), "seen": seen})
+	t.Error(err)
+
+	res, _, _, err := t.db.Fetch(ct, 0)
+	t.Nil(err)
+	t.Equal(time.Date(1985, 7, 21, 0, 0, 0, 0, time.UTC), res["born"])
+	t.True(seen.Equal(res["seen"].(time.Time)))
+
+	// The calendar day is kept as written, whatever the time zone
+	res, _, _, err = t.db.Fetch(ct, 3)
+	t.Nil(err)
+	t.Equal(time.Date(1985, 7, 20, 0, 0, 0, 0, time.UTC), res["born"])
+	t.Equal(time.Date(2024, 3, 5, 9, 0, 0, 500000000, time.UTC), res["seen"])
+
+	err = t.db.Use(ct, "types_born")
+	t.Nil(err)
+
+	err = t.db.First(ct)
+	t.Nil(err)
+	expected := []int64{1, 3, 0, 2}
+	for i, recNo := range expected {
+		t.Equal(recNo, ct.CursorPos())
+		eof, err := t.db.Next(ct)
+		t.Nil(err)
+		t.Equal(i == len(expected)-1, eof)
+	}
+
+	// Soft seek positions on the nearest date for range reads
+	ct.SetSoftSeek(true)
+	_, err = t.db.Seek(ct, "1985-01-01")
+	t.Nil(err)
+	t.Equal(int64(3), ct.CursorPos())
+
+	res, err = t.db.Locate(ct, "born", time.Date(2001, 1, 2, 0, 0, 0, 0, time.UTC))
+	t.Nil(err)
+	t.Equal(int64(2), res["_recNo"])
+}
+
+func (t *fieldTypesTestSuite) TestDescendingIndex() {
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "created", Type: FtDate, Indexes: []IndexDef{{Name: "desc_created", Descending: true}}},
+			{Name: "qty", Type: FtInt},
+		},
+		Indexes: []IndexDef{{Name: "desc_qty_created", Fields: []string{"qty", "created"}, Descending: true}},
+	}
+	err := t.db.Create("desc_tests", tableStruct)
+	t.Nil(err)
+
+	ct, err := t.db.Open("desc_tests")
+	t.Nil(err)
+	defer ct.Close()
+
+	rows := []map[string]interface{}{
+		{"created": "2024-01-10", "qty": 1},
+		{"created": "2023-05-01", "qty": 2},
+		{"created": "2024-03-01", "qty": 1},
+		{"created": "2022-12-31", "qty": 3},
+	}
+	for _, row := range rows {
+		_, err := t.db.Insert(ct, row)
+		t.Nil(err)
+	}
+
+	walk := func() []int64 {
+		recNos := make([]int64, 0)
+		err := t.db.First(ct)
+		t.Nil(err)
+		for {
+			recNos = append(recNos, ct.CursorPos())
+			eof, err := t.db.Next(ct)
+			t.Nil(err)
+			if eof {
+				return recNos
+			}
+		}
+	}
+
+	err = t.db.Use(ct, "desc_created")
+	t.Nil(err)
+	t.Equal([]int64{2, 0, 1, 3}, walk())
+
+	err = t.db.Last(ct)
+	t.Nil(err)
+	t.Equal(int64(3), ct.CursorPos())
+
+	// Soft seek lands on the next key in the index order, the next older date
+	ct.SetSoftSeek(true)
+	_, err = t.db.Seek(ct, "2024-01-01")
+	t.Nil(err)
+	t.Equal(int64(1), ct.CursorPos())
+
+	err = t.db.Use(ct, "desc_qty_created")
+	t.Nil(err)
+	t.Equal([]int64{3, 1, 2, 0}, walk())
+}
+
+func (t *fieldTypesTestSuite) TestIntAndBoolSeek() {
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "qty", Type: FtInt, Nullable: true, Indexes: []IndexDef{{Name: "types_qty"}}},
+			{Name: "active", Type: FtBool, Indexes: []IndexDef{{Name: "types_active"}}},
+			{Name: "stock", Type: FtInt},
+		},
+	}
+	err := t.db.Create("int_types_tests", tableStruct)
+	t.Nil(err)
+
+	ct, err := t.db.Open("int_types_tests")
+	t.Nil(err)
+	defer ct.Close()
+
+	rows := []map[string]interface{}{
+		{"qty": 30, "active": true, "stock": 7},
+		{"qty": -5, "active": false, "stock": 8},
+		{"qty": nil, "active": true, "stock": 9},
+		{"qty": 12, "active": false, "stock": 10},
+	}
+	for _, row := range rows {
+		_, err := t.db.Insert(ct, row)
+		t.Nil(err)
+	}
+
+	err = t.db.Use(ct, "types_qty")
+	t.Nil(err)
+
+	found, err := t.db.Seek(ct, 12)
+	t.Nil(err)
+	t.True(found)
+	t.Equal(int64(3), ct.CursorPos())
+
+	// Not found goes to the end of file, with soft seek to the next greater key
+	found, err = t.db.Seek(ct, int64(0))
+	t.Nil(err)
+	t.False(found)
+	t.True(ct.Eof())
+
+	ct.SetSoftSeek(true)
+	found, err = t.db.Seek(ct, int64(0))
+	t.Nil(err)
+	t.False(found)
+	t.Equal(int64(3), ct.CursorPos())
+
+	_, err = t.db.Seek(ct, -5.0)
+	t.Nil(err)
+	t.Equal(int64(1), ct.CursorPos())
+
+	_, err = t.db.Seek(ct, "12")
+	t.EqualError(err, "value 12 is not a key of index types_qty")
+
+	res, err := t.db.Locate(ct, "qty", int64(30))
+	t.Nil(err)
+	t.Equal(int64(0), res["_recNo"])
+
+	_, err = t.db.Locate(ct, "qty", 31)
+	t.ErrorIs(err, errNotFound)
+
+	err = t.db.Use(ct, "types_active")
+	t.Nil(err)
+
+	_, err = t.db.Seek(ct, true)
+	t.Nil(err)
+	t.Equal(int64(0), ct.CursorPos())
+
+	res, err = t.db.Locate(ct, "active", false)
+	t.Nil(err)
+	t.Equal(int64(1), res["_recNo"])
+
+	// A field without index is scanned, the values are compared by the field type
+	res, err = t.db.Locate(ct, "stock", 9)
+	t.Nil(err)
+	t.Equal(int64(2), res["_recNo"])
+}
@@ -0,0 +1,163 @@
+package localdb
+
+import (
+	filemanager "godb/pkg/file"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type filterTestSuite struct {
+	suite.Suite
+	db Manager
+	ct *CurrentTable
+}
+
+func TestFilterRunner(t *testing.T) {
+	suite.Run(t, new(filterTestSuite))
+}
+
+func (t *filterTestSuite) SetupTest() {
+	err := os.RemoveAll(filemanager.DefaultFolder)
+	if err != nil {
+		panic("Cannot run test, the folder cannot be removed " + err.Error())
+	}
+
+	t.db = New()
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "name", Type: FtText, Length: 10, Indexes: []IndexDef{
+				{Name: "filter_active_name", Filter: "active .AND. balance > 0"},
+				{Name: "filter_unique_name", Filter: "active", Unique: true},
+			}},
+			{Name: "active", Type: FtBool},
+			{Name: "balance", Type: FtReal},
+		},
+		Indexes: []IndexDef{
+			{Name: "filter_upper_name", Expression: "UPPER(name)", Filter: "NOT active"},
+		},
+	}
+	tableName := "filter_tests"
+	err = t.db.Create(tableName, tableStruct)
+	if err != nil {
+		panic("Cannot run test, Could not create database " + err.Error())
+	}
+
+	ct, err := t.db.Open(tableName)
+	if err != nil {
+		panic("Cannot open table " + err.Error())
+	}
+
+	t.ct = ct
+
+	rows := []map[string]interface{}{
+		{"name": "john", "active": true, "balance": 10.5},
+		{"name": "anna", "active": false, "balance": 3},
+		{"name": "bob", "active": true, "balance": 0},
+		{"name": "carl", "active": true, "balance": 1},
+		{"name": "john", "active": false, "balance": 7},
+	}
+	for _, row := range rows {
+		_, err = t.db.Insert(t.ct, row)
+		if err != nil {
+			panic("Cannot insert test data " + err.Error())
+		}
+	}
+}
+
+func (t *filterTestSuite) TearDownTest() {
+	t.ct.Close()
+	t.db = nil
+}
+
+func (t *filterTestSuite) walk() []int64 {
+	recNos := make([]int64, 0)
+	err := t.db.First(t.ct)
+	t.Nil(err)
+
+	for {
+		recNos = append(recNos, t.ct.CursorPos())
+		eof, err := t.db.Next(t.ct)
+		t.Nil(err)
+		if eof {
+			return recNos
+		}
+	}
+}
+
+func (t *filterTestSuite) TestUseVisitsMatchingRows() {
+	err := t.db.Use(t.ct, "filter_active_name")
+	t.Nil(err)
+	t.Equal([]int64{3, 0}, t.walk())
+
+	err = t.db.Use(t.ct, "filter_unique_name")
+	t.Nil(err)
+	t.Equal([]int64{2, 3, 0}, t.walk())
+
+	err = t.db.Use(t.ct, "filter_upper_name")
+	t.Nil(err)
+	t.Equal([]int64{1, 4}, t.walk())
+
+	// Filtered indexes are rebuilt by pack with the same condition
+	err = t.db.Delete(t.ct, 3)
+	t.Nil(err)
+	_, err = t.db.Pack(t.ct)
+	t.Nil(err)
+
+	err = t.db.Use(t.ct, "filter_active_name")
+	t.Nil(err)
+	t.Equal([]int64{0}, t.walk())
+}
+
+func (t *filterTestSuite) TestUpdateMovesRowsInAndOut() {
+	err := t.db.Update(t.ct, 2, map[string]interface{}{"balance": 5})
+	t.Nil(err)
+
+	err = t.db.Update(t.ct, 0, map[string]interface{}{"active": false})
+	t.Nil(err)
+
+	err = t.db.Use(t.ct, "filter_active_name")
+	t.Nil(err)
+	t.Equal([]int64{2, 3}, t.walk())
+
+	err = t.db.Use(t.ct, "filter_upper_name")
+	t.Nil(err)
+	recNos := t.walk()
+	t.Equal(int64(1), recNos[0])
+	t.ElementsMatch([]int64{0, 4}, recNos[1:])
+
+	err = t.db.Delete(t.ct, 1)
+	t.Nil(err)
+	err = t.db.Recall(t.ct, 1)
+	t.Nil(err)
+	t.Equal(int64(1), t.walk()[0])
+}
+
+func (t *filterTestSuite) TestUniqueOnlyAmongMatchingRows() {
+	// An inactive john already exists, only active rows have to be unique
+	_, err := t.db.Insert(t.ct, map[string]interface{}{"name": "john", "active": true, "balance": 1})
+	t.ErrorIs(err, ErrDuplicateKey)
+
+	_, err = t.db.Insert(t.ct, map[string]interface{}{"name": "bob", "active": false, "balance": 1})
+	t.Nil(err)
+
+	err = t.db.Update(t.ct, 4, map[string]interface{}{"active": true})
+	t.ErrorIs(err, ErrDuplicateKey)
+
+	err = t.db.Update(t.ct, 1, map[string]interface{}{"active": true})
+	t.Nil(err)
+}
+
+func (t *filterTestSuite) TestInvalidFilter() {
+	for _, filter := range []string{"missing", "UPPER(name)", "name = 1", "active AND"} {
+		tableStruct := &FieldDef{
+			Fields: []Field{
+				{Name: "name", Type: FtText, Length: 10, Indexes: []IndexDef{{Name: "filter_invalid", Filter: filter}}},
+				{Name: "active", Type: FtBool},
+			},
+		}
+		err := t.db.Create("filter_invalid", tableStruct)
+		t.Error(err, filter)
+	}
+}
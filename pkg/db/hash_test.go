@@ -0,0 +1,149 @@
+package localdb
+
+import (
+	filemanager "godb/pkg/file"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type hashIndexTestSuite struct {
+	suite.Suite
+	db Manager
+	ct *CurrentTable
+}
+
+func TestHashIndexRunner(t *testing.T) {
+	suite.Run(t, new(hashIndexTestSuite))
+}
+
+func (t *hashIndexTestSuite) SetupTest() {
+	err := os.RemoveAll(filemanager.DefaultFolder)
+	if err != nil {
+		panic("Cannot run test, the folder cannot be removed " + err.Error())
+	}
+
+	t.db = New()
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "code", Type: FtText, Length: 10, Indexes: []IndexDef{{Name: "hash_code", Type: IndexTypeHash, Unique: true}}},
+			{Name: "qty", Type: FtInt, Nullable: true, Indexes: []IndexDef{{Name: "hash_qty", Type: IndexTypeHash}}},
+			{Name: "name", Type: FtText, Length: 10, Indexes: []IndexDef{{Name: "hash_name_order"}}},
+		},
+	}
+	tableName := "hash_tests"
+	err = t.db.Create(tableName, tableStruct)
+	if err != nil {
+		panic("Cannot run test, Could not create database " + err.Error())
+	}
+
+	ct, err := t.db.Open(tableName)
+	if err != nil {
+		panic("Cannot open table " + err.Error())
+	}
+
+	t.ct = ct
+
+	rows := []map[string]interface{}{
+		{"code": "a1", "qty": 5, "name": "john"},
+		{"code": "b2", "qty": nil, "name": "anna"},
+		{"code": "c3", "qty": 5, "name": "bob"},
+		{"code": "d4", "qty": 7, "name": "carl"},
+	}
+	for _, row := range rows {
+		_, err = t.db.Insert(t.ct, row)
+		if err != nil {
+			panic("Cannot insert test data " + err.Error())
+		}
+	}
+}
+
+func (t *hashIndexTestSuite) TearDownTest() {
+	t.ct.Close()
+	t.db = nil
+}
+
+func (t *hashIndexTestSuite) TestLocate() {
+	res, err := t.db.Locate(t.ct, "code", "c3")
+	t.Nil(err)
+	t.Equal(int64(2), res["_recNo"])
+	t.Equal(int64(2), t.ct.CursorPos())
+
+	res, err = t.db.Locate(t.ct, "qty", 5)
+	t.Nil(err)
+	t.Equal(int64(0), res["_recNo"])
+
+	res, err = t.db.Locate(t.ct, "qty", nil)
+	t.Nil(err)
+	t.Equal(int64(1), res["_recNo"])
+
+	_, err = t.db.Locate(t.ct, "code", "zz")
+	t.Error(err)
+
+	// Deleted and updated records leave the hash index
+	err = t.db.Delete(t.ct, 0)
+	t.Nil(err)
+	res, err = t.db.Locate(t.ct, "qty", 5)
+	t.Nil(err)
+	t.Equal(int64(2), res["_recNo"])
+
+	err = t.db.Update(t.ct, 3, map[string]interface{}{"code": "e5"})
+	t.Nil(err)
+	_, err = t.db.Locate(t.ct, "code", "d4")
+	t.Error(err)
+	res, err = t.db.Locate(t.ct, "code", "e5")
+	t.Nil(err)
+	t.Equal(int64(3), res["_recNo"])
+
+	// Pack rebuilds it with the new record numbers
+	_, err = t.db.Pack(t.ct)
+	t.Nil(err)
+	res, err = t.db.Locate(t.ct, "code", "e5")
+	t.Nil(err)
+	t.Equal(int64(2), res["_recNo"])
+}
+
+func (t *hashIndexTestSuite) TestUniqueHash() {
+	_, err := t.db.Insert(t.ct, map[string]interface{}{"code": "a1", "qty": 1, "name": "x"})
+	t.ErrorIs(err, ErrDuplicateKey)
+
+	err = t.db.Update(t.ct, 1, map[string]interface{}{"code": "d4"})
+	t.ErrorIs(err, ErrDuplicateKey)
+}
+
+func (t *hashIndexTestSuite) TestHashHasNoOrder() {
+	err := t.db.Use(t.ct, "hash_code")
+	t.Nil(err)
+
+	_, err = t.db.Seek(t.ct, "a1")
+	t.EqualError(err, "index hash_code is a hash index, it has no order for seek and navigation")
+
+	_, err = t.db.Next(t.ct)
+	t.Error(err)
+	_, err = t.db.Prev(t.ct)
+	t.Error(err)
+	t.Error(t.db.First(t.ct))
+	t.Error(t.db.Last(t.ct))
+
+	// Locate still works, a field without index is scanned
+	res, err := t.db.Locate(t.ct, "code", "d4")
+	t.Nil(err)
+	t.Equal(int64(3), res["_recNo"])
+
+	res, err = t.db.Locate(t.ct, "name", "bob")
+	t.Nil(err)
+	t.Equal(int64(2), res["_recNo"])
+
+	err = t.db.Use(t.ct, "hash_name_order")
+	t.Nil(err)
+	err = t.db.First(t.ct)
+	t.Nil(err)
+	t.Equal(int64(1), t.ct.CursorPos())
+
+	tableStruct := &FieldDef{
+		Fields: []Field{{Name: "code", Type: FtText, Length: 10, Indexes: []IndexDef{{Name: "hash_desc", Type: IndexTypeHash, Descending: true}}}},
+	}
+	err = t.db.Create("hash_invalid", tableStruct)
+	t.Error(err)
+}
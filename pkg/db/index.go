@@ -0,0 +1,459 @@
+package localdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"godb/pkg/btree"
+	"godb/pkg/expr"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	// IndexTypeHash is an on-disk hash index, it finds equal keys (Locate) but it has no order (Seek, Next)
+	IndexTypeHash = "hash"
+	// IndexTypeBinaryTree is an unbalanced binary tree index, ordered like the btree one
+	IndexTypeBinaryTree = "bintree"
+)
+
+// tableIndex is an index of the table with the fields building it's keys, a field index, a compound or an expression one
+type tableIndex struct {
+	IndexDef
+	fields     []Field
+	compound   bool
+	expression *expr.Expression
+	keySize    int // key size of the expression index
+	filter     *expr.Expression
+	filterBy   []Field // fields used by the filter
+}
+
+// tableIndexes lists the field indexes then the compound indexes of the table definition
+func tableIndexes(def *FieldDef) ([]tableIndex, error) {
+	indexes := make([]tableIndex, 0)
+	for _, field := range def.Fields {
+		for _, index := range field.Indexes {
+			indexes = append(indexes, tableIndex{IndexDef: index, fields: []Field{field}})
+		}
+	}
+
+	for _, index := range def.Indexes {
+		if index.Expression != "" {
+			if len(index.Fields) > 0 {
+				return nil, fmt.Errorf("index %s has both fields and expression", index.Name)
+			}
+
+			expIndex, err := expressionIndex(def, index)
+			if err != nil {
+				return nil, err
+			}
+			indexes = append(indexes, expIndex)
+			continue
+		}
+
+		if len(index.Fields) == 0 {
+			return nil, fmt.Errorf("compound index %s has no fields", index.Name)
+		}
+
+		fields := make([]Field, 0, len(index.Fields))
+		for _, name := range index.Fields {
+			field, ok := def.field(name)
+			if !ok {
+				return nil, fmt.Errorf("compound index %s refers to unknown field %s", index.Name, name)
+			}
+
+			if field.isMemo() {
+				return nil, fmt.Errorf("memo and blob field %s cannot be indexed", field.Name)
+			}
+			fields = append(fields, *field)
+		}
+		indexes = append(indexes, tableIndex{IndexDef: index, fields: fields, compound: true})
+	}
+
+	for x := range indexes {
+		if _, err := indexFactory(indexes[x].Type); err != nil {
+			return nil, fmt.Errorf("index %s: %w", indexes[x].Name, err)
+		}
+
+		if indexes[x].isHash() && indexes[x].Descending {
+			return nil, fmt.Errorf("hash index %s has no order, it cannot be descending", indexes[x].Name)
+		}
+
+		err := indexes[x].parseFilter(def)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return indexes, nil
+}
+
+// expressionFields returns the table fields used by the expression, memo fields cannot be used
+func expressionFields(def *FieldDef, index IndexDef, expression *expr.Expression) ([]Field, error) {
+	fields := make([]Field, 0, len(expression.Fields()))
+	for _, name := range expression.Fields() {
+		field, ok := def.field(name)
+		if !ok {
+			return nil, fmt.Errorf("expression of index %s refers to unknown field %s", index.Name, name)
+		}
+
+		if field.isMemo() {
+			return nil, fmt.Errorf("memo and blob field %s cannot be used in index %s", field.Name, index.Name)
+		}
+		fields = append(fields, *field)
+	}
+
+	return fields, nil
+}
+
+// emptyRecord holds the zero value of the fields, evaluating expressions on it reports the type errors at creation
+func emptyRecord(fields []Field) map[string]interface{} {
+	data := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		data[field.Name] = field.zeroValue()
+	}
+
+	return data
+}
+
+// parseFilter parses the filter condition of the index
+func (t *tableIndex) parseFilter(def *FieldDef) error {
+	if t.Filter == "" {
+		return nil
+	}
+
+	filter, err := expr.Parse(t.Filter)
+	if err != nil {
+		return fmt.Errorf("invalid filter of index %s: %w", t.Name, err)
+	}
+
+	t.filter = filter
+	t.filterBy, err = expressionFields(def, t.IndexDef, filter)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.filter.Match(resolver(&ins{}, t.filterBy, emptyRecord(t.filterBy)))
+	if err != nil {
+		return fmt.Errorf("invalid filter of index %s: %w", t.Name, err)
+	}
+
+	return nil
+}
+
+// includes reports if the record belongs to the index, skipped nulls and records not matching the filter are left out
+func (t tableIndex) includes(i *ins, data map[string]interface{}) (bool, error) {
+	if t.SkipNulls && t.isNull(data) {
+		return false, nil
+	}
+
+	if t.filter == nil {
+		return true, nil
+	}
+
+	match, err := t.filter.Match(resolver(i, t.filterBy, data))
+	if err != nil {
+		return false, fmt.Errorf("filter of index %s: %w", t.Name, err)
+	}
+
+	return match, nil
+}
+
+// expressionIndex parses the expression of the index and checks that it gives a text key on the fields of the table
+func expressionIndex(def *FieldDef, index IndexDef) (tableIndex, error) {
+	expression, err := expr.Parse(index.Expression)
+	if err != nil {
+		return tableIndex{}, fmt.Errorf("invalid expression of index %s: %w", index.Name, err)
+	}
+
+	fields, err := expressionFields(def, index, expression)
+	if err != nil {
+		return tableIndex{}, err
+	}
+
+	t := tableIndex{IndexDef: index, fields: fields, expression: expression}
+	t.keySize, err = expression.Width(t.fieldWidth)
+	if err != nil {
+		return tableIndex{}, fmt.Errorf("invalid expression of index %s: %w", index.Name, err)
+	}
+
+	if t.keySize <= 0 {
+		return tableIndex{}, fmt.Errorf("expression of index %s gives an empty key", index.Name)
+	}
+
+	_, err = t.expressionKey(&ins{}, emptyRecord(fields))
+
+	return t, err
+}
+
+func (f Field) zeroValue() interface{} {
+	switch f.Type {
+	case FtText:
+		return ""
+	case FtBool:
+		return false
+	case FtInt:
+		return int64(0)
+	case FtReal:
+		return float64(0)
+	case FtDate, FtDateTime:
+		return time.Unix(0, 0)
+	}
+
+	return nil
+}
+
+// isFieldIndex reports if the index is built on the value of a single field
+func (t tableIndex) isFieldIndex() bool {
+	return !t.compound && t.expression == nil
+}
+
+// fieldWidth is the text width of the field used in the expression
+func (t tableIndex) fieldWidth(name string) (int, error) {
+	for _, field := range t.fields {
+		if field.Name == name && field.Type == FtText {
+			return field.Length, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// open opens (or creates) the index with the factory registered for the index type
+func (t *tableIndex) open() error {
+	factory, err := indexFactory(t.Type)
+	if err != nil {
+		return err
+	}
+
+	t.index, err = factory(IndexSpec{Name: t.Name, KeySize: t.keyLength(), Options: t.keyOptions()})
+
+	return err
+}
+
+// keyOptions returns the type, null flag and order of the keys
+func (t tableIndex) keyOptions() btree.Options {
+	if t.expression != nil {
+		return btree.Options{KeyType: btree.KeyText, Descending: t.Descending}
+	}
+
+	if !t.compound {
+		opts := t.fields[0].indexOptions()
+		opts.Descending = t.Descending
+
+		return opts
+	}
+
+	return btree.Options{KeyType: btree.KeyBinary, Descending: t.Descending}
+}
+
+// keyLength is the size of the keys built by key()
+func (t tableIndex) keyLength() int {
+	if t.expression != nil {
+		return t.keySize
+	}
+
+	if !t.compound {
+		return sortableKeySize(t.fields[0])
+	}
+
+	return compoundKeySize(t.fields)
+}
+
+func (t tableIndex) isHash() bool {
+	return t.Type == IndexTypeHash
+}
+
+// ordered returns the index if it keeps the order of the keys
+func (t tableIndex) ordered() (OrderedIndex, bool) {
+	index, ok := t.index.(OrderedIndex)
+
+	return index, ok
+}
+
+// isNull reports if every field of the key is null
+func (t tableIndex) isNull(data map[string]interface{}) bool {
+	for _, field := range t.fields {
+		if data[field.Name] != nil || !field.Nullable {
+			return false
+		}
+	}
+
+	return true
+}
+
+// value returns the value of the field, the values of the compound index fields or the result of the expression
+func (t tableIndex) value(data map[string]interface{}) interface{} {
+	if t.expression != nil {
+		key, err := t.expressionKey(&ins{}, data)
+		if err != nil {
+			return nil
+		}
+
+		return strings.TrimRight(string(key), " ")
+	}
+
+	if !t.compound {
+		return data[t.fields[0].Name]
+	}
+
+	values := make([]interface{}, len(t.fields))
+	for x, field := range t.fields {
+		values[x] = data[field.Name]
+	}
+
+	return values
+}
+
+// key builds the index key from the record data
+func (i *ins) key(index tableIndex, data map[string]interface{}) ([]byte, error) {
+	if index.expression != nil {
+		return index.expressionKey(i, data)
+	}
+
+	if !index.compound {
+		field := index.fields[0]
+		return i.indexKey(field, data[field.Name])
+	}
+
+	return i.compoundKey(index.fields, index.value(data).([]interface{}))
+}
+
+// expressionKey evaluates the expression of the index on the data, the text is cut to the key size
+func (t tableIndex) expressionKey(i *ins, data map[string]interface{}) ([]byte, error) {
+	result, err := t.expression.Eval(resolver(i, t.fields, data))
+	if err != nil {
+		return nil, fmt.Errorf("expression of index %s: %w", t.Name, err)
+	}
+
+	text, ok := result.(string)
+	if !ok {
+		return nil, fmt.Errorf("expression of index %s must give a text, got %T", t.Name, result)
+	}
+
+	return []byte(truncateText(text, t.keySize)), nil
+}
+
+// resolver reads the fields of the expression from the data
+func resolver(i *ins, fields []Field, data map[string]interface{}) expr.Resolver {
+	return func(name string) (interface{}, error) {
+		for _, field := range fields {
+			if field.Name == name {
+				return i.expressionValue(field, data[name])
+			}
+		}
+
+		return nil, fmt.Errorf("unknown field %s", name)
+	}
+}
+
+// expressionValue converts the field value to the type used in expressions. Texts are padded with spaces to the field
+// length like in dBase, so the concatenated fields keep their order
+func (i *ins) expressionValue(field Field, value interface{}) (interface{}, error) {
+	if value == nil {
+		if field.Type == FtText {
+			return strings.Repeat(" ", field.Length), nil
+		}
+
+		return nil, nil
+	}
+
+	buf, err := i.convertToFileData(field, value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch field.Type {
+	case FtText:
+		text := string(bytes.TrimRight(buf, "\x00"))
+		return text + strings.Repeat(" ", field.Length-len(text)), nil
+	case FtBool:
+		return buf[0] != 0, nil
+	case FtInt:
+		return int64(binary.LittleEndian.Uint64(buf)), nil
+	case FtReal:
+		return math.Float64frombits(binary.LittleEndian.Uint64(buf)), nil
+	case FtDate:
+		return time.Unix(int64(binary.LittleEndian.Uint64(buf)), 0).UTC(), nil
+	case FtDateTime:
+		return time.UnixMicro(int64(binary.LittleEndian.Uint64(buf))).UTC(), nil
+	}
+
+	return nil, fmt.Errorf("field type %d is not supported in expressions", field.Type)
+}
+
+// compoundKey concatenates the order preserving encoding of the values. Fewer values than fields give a key prefix,
+// the missing fields are filled with zeros, which is less than any encoded value, so seek lands on the first matching key
+func (i *ins) compoundKey(fields []Field, values []interface{}) ([]byte, error) {
+	if len(values) > len(fields) {
+		return nil, fmt.Errorf("compound key has %d fields, got %d values", len(fields), len(values))
+	}
+
+	key := make([]byte, 0, compoundKeySize(fields))
+	for x, value := range values {
+		part, err := i.sortableKey(fields[x], value)
+		if err != nil {
+			return nil, err
+		}
+		key = append(key, part...)
+	}
+
+	return append(key, make([]byte, compoundKeySize(fields)-len(key))...), nil
+}
+
+// sortableKey encodes the value so that comparing the bytes gives the order of the values
+func (i *ins) sortableKey(field Field, value interface{}) ([]byte, error) {
+	prefix := []byte{}
+	if field.Nullable {
+		if value == nil {
+			return make([]byte, sortableKeySize(field)), nil
+		}
+		prefix = []byte{btree.ValueFlag}
+	}
+
+	buf, err := i.convertToFileData(field, value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch field.Type {
+	case FtInt, FtDate, FtDateTime:
+		// big endian with flipped sign bit, negative numbers come first
+		num := binary.LittleEndian.Uint64(buf)
+		binary.BigEndian.PutUint64(buf, num^(1<<63))
+	case FtReal:
+		// positive numbers get the sign bit set, negative ones are inverted, NaN is the smallest
+		bits := binary.LittleEndian.Uint64(buf)
+		switch {
+		case math.IsNaN(math.Float64frombits(bits)):
+			bits = 0
+		case bits&(1<<63) != 0:
+			bits = ^bits
+		default:
+			bits |= 1 << 63
+		}
+		binary.BigEndian.PutUint64(buf, bits)
+	}
+
+	return append(prefix, buf...), nil
+}
+
+func compoundKeySize(fields []Field) int {
+	size := 0
+	for _, field := range fields {
+		size += sortableKeySize(field)
+	}
+
+	return size
+}
+
+func sortableKeySize(field Field) int {
+	// the sizes are already validated when the table was opened
+	size, _ := field.size()
+	if field.Nullable {
+		size++
+	}
+
+	return size
+}
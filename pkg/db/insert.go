@@ -3,11 +3,22 @@ package localdb
 import (
 	"encoding/binary"
 	"fmt"
+	"godb/pkg/btree"
 	filemanager "godb/pkg/file"
 	"io"
+	"math"
 	"sync"
+	"time"
 )
 
+// dateLayouts are the accepted ISO-8601 string formats of date and date time fields
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
 type inserter interface {
 	Insert(*CurrentTable, map[string]interface{}) (*CurrentTable, error)
 }
@@ -25,6 +36,21 @@ func newInserter() inserter {
 
 func (i *ins) Insert(c *CurrentTable, data map[string]interface{}) (*CurrentTable, error) {
 	i.CurrentTable = c
+	data, err := i.withDefaults(data)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateRequired(c.fieldDef.Fields, data, false)
+	if err != nil {
+		return nil, err
+	}
+
+	err = i.checkUnique(data)
+	if err != nil {
+		return nil, err
+	}
+
 	record, err := i.dataAsBytes(data)
 	if err != nil {
 		return nil, err
@@ -47,35 +73,32 @@ func (i *ins) Insert(c *CurrentTable, data map[string]interface{}) (*CurrentTabl
 
 func (i *ins) addToIndexIfIndexed(data map[string]interface{}, recordPtr int64) error {
 	var wg sync.WaitGroup
-	for _, field := range i.CurrentTable.fieldDef.Fields {
-		if field.Indexes != nil {
-			for _, index := range field.Indexes {
-				var value interface{}
-				var err error
-				if val, ok := data[field.Name]; ok {
-					value = val
-				}
-
-				buf, err := i.convertToFileData(field, value)
-				if err != nil {
-					return err
-				}
-				index := *index.index
-
-				// err = index.Insert(buf, recordPtr)
-				// if err != nil {
-				// 	return err
-				// }
-
-				wg.Add(1)
-				go func(b []byte, r int64) {
-					defer wg.Done()
-					index.Insert(b, r)
-				}(buf, recordPtr)
-			}
+	for _, index := range i.CurrentTable.indexes {
+		included, err := index.includes(i, data)
+		if err != nil {
+			return err
+		}
 
+		if !included {
+			continue
 		}
 
+		buf, err := i.key(index, data)
+		if err != nil {
+			return err
+		}
+		keys := index.index
+
+		// err = index.Insert(buf, recordPtr)
+		// if err != nil {
+		// 	return err
+		// }
+
+		wg.Add(1)
+		go func(b []byte, r int64) {
+			defer wg.Done()
+			keys.Insert(b, r)
+		}(buf, recordPtr)
 	}
 
 	wg.Wait()
@@ -116,15 +139,66 @@ func (i *ins) addToRecordPointerFile(value int64) (int64, error) {
 }
 
 func (i *ins) dataAsBytes(data map[string]interface{}) ([]byte, error) {
-	result := make([]byte, 0)
+	return i.recordAsBytes(data, nil)
+}
 
-	for _, field := range i.CurrentTable.fieldDef.Fields {
+// recordAsBytes converts the data to a record. Memo values are only written when every field converted fine,
+// the memo chunks of the old record are reused, memo fields missing from the data keep the old chunk
+func (i *ins) recordAsBytes(data map[string]interface{}, oldRecord []byte) ([]byte, error) {
+	result := make([]byte, i.CurrentTable.fieldDef.nullBitmapSize())
+	memoOffsets := make([]int, 0)
+	memos := make([][]byte, 0)
+
+	for x, field := range i.CurrentTable.fieldDef.Fields {
 		var value interface{}
 		var err error
-		if val, ok := data[field.Name]; ok {
+		val, ok := data[field.Name]
+		if ok {
 			value = val
 		}
 
+		isNullValue := value == nil && field.Nullable
+		if isNullValue {
+			setNull(result, x)
+		}
+
+		if field.isMemo() {
+			offset := len(result)
+			result = append(result, make([]byte, filemanager.Int64Length)...)
+			if !ok && oldRecord != nil {
+				copy(result[offset:], oldRecord[offset:offset+filemanager.Int64Length])
+				if field.Nullable && isNull(oldRecord, x) {
+					setNull(result, x)
+				}
+				continue
+			}
+
+			// null memo is stored as empty, so the old chunk gets freed
+			buf, err := i.convertFkMemo(field, value)
+			if err != nil {
+				return nil, err
+			}
+			memoOffsets = append(memoOffsets, offset)
+			memos = append(memos, buf)
+			continue
+		}
+
+		if isNullValue {
+			size, err := field.size()
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, make([]byte, size)...)
+			continue
+		}
+
+		if field.Type == FtText {
+			err = checkOverflow(field, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		converted, err := i.convertToFileData(field, value)
 		if err != nil {
 			return nil, err
@@ -132,9 +206,41 @@ func (i *ins) dataAsBytes(data map[string]interface{}) ([]byte, error) {
 		result = append(result, converted...)
 	}
 
+	for x, buf := range memos {
+		offset := memoOffsets[x]
+		var oldPtr int64
+		if oldRecord != nil {
+			oldPtr = int64(binary.LittleEndian.Uint64(oldRecord[offset:]))
+		}
+
+		ptr, err := i.CurrentTable.memo().write(oldPtr, buf)
+		if err != nil {
+			return nil, err
+		}
+		binary.LittleEndian.PutUint64(result[offset:], uint64(ptr))
+	}
+
 	return result, nil
 }
 
+// indexKey converts the value to the key of the field indexes, keys of nullable fields are prefixed with the null flag
+func (i *ins) indexKey(field Field, value interface{}) ([]byte, error) {
+	if !field.Nullable {
+		return i.convertToFileData(field, value)
+	}
+
+	if value == nil {
+		return []byte{btree.NullFlag}, nil
+	}
+
+	key, err := i.convertToFileData(field, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{btree.ValueFlag}, key...), nil
+}
+
 func (i *ins) convertToFileData(field Field, value interface{}) ([]byte, error) {
 	switch field.Type {
 	case FtText:
@@ -143,7 +249,12 @@ func (i *ins) convertToFileData(field Field, value interface{}) ([]byte, error)
 		return i.convertFkBool(field, value)
 	case FtInt:
 		return i.convertFkInt(field, value)
-
+	case FtReal:
+		return i.convertFkReal(field, value)
+	case FtDate:
+		return i.convertFkDate(field, value)
+	case FtDateTime:
+		return i.convertFkDateTime(field, value)
 	}
 
 	return nil, fmt.Errorf("non implemented field type")
@@ -152,13 +263,27 @@ func (i *ins) convertToFileData(field Field, value interface{}) ([]byte, error)
 func (i *ins) convertFkText(field Field, value interface{}) ([]byte, error) {
 	if val, ok := value.(string); ok {
 		res := make([]byte, field.Length)
-		copy(res, val)
+		copy(res, truncateText(val, field.Length))
 		return res, nil
 	}
 
 	return nil, fmt.Errorf("field %s requires string value in data map", field.Name)
 }
 
+// convertFkMemo returns the bytes to store in the memo file, missing value is an empty memo
+func (i *ins) convertFkMemo(field Field, value interface{}) ([]byte, error) {
+	switch val := value.(type) {
+	case nil:
+		return []byte{}, nil
+	case string:
+		return []byte(val), nil
+	case []byte:
+		return val, nil
+	}
+
+	return nil, fmt.Errorf("field %s requires string or []byte value in data map", field.Name)
+}
+
 func (i *ins) convertFkBool(field Field, value interface{}) ([]byte, error) {
 	if val, ok := value.(bool); ok {
 		if val {
@@ -195,3 +320,70 @@ func (i *ins) convertFkInt(field Field, value interface{}) ([]byte, error) {
 
 	return nil, fmt.Errorf("field %s requires int64 value in data map", field.Name)
 }
+
+func (i *ins) convertFkReal(field Field, value interface{}) ([]byte, error) {
+	var float float64
+	switch val := value.(type) {
+	case float64:
+		float = val
+	case float32:
+		float = float64(val)
+	case int64:
+		float = float64(val)
+	case int:
+		float = float64(val)
+	default:
+		return nil, fmt.Errorf("field %s requires float64 value in data map", field.Name)
+	}
+
+	buf := make([]byte, filemanager.Float64Length)
+	binary.LittleEndian.PutUint64(buf, math.Float64bits(float))
+
+	return buf, nil
+}
+
+// convertFkDate stores the calendar day of the value as unix seconds of the UTC midnight, it keeps the chronological order as int64
+func (i *ins) convertFkDate(field Field, value interface{}) ([]byte, error) {
+	t, err := i.timeValue(field, value)
+	if err != nil {
+		return nil, err
+	}
+
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	buf := make([]byte, filemanager.Int64Length)
+	binary.LittleEndian.PutUint64(buf, uint64(day.Unix()))
+
+	return buf, nil
+}
+
+// convertFkDateTime stores the value as unix microseconds, it keeps the chronological order as int64
+func (i *ins) convertFkDateTime(field Field, value interface{}) ([]byte, error) {
+	t, err := i.timeValue(field, value)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, filemanager.Int64Length)
+	binary.LittleEndian.PutUint64(buf, uint64(t.UnixMicro()))
+
+	return buf, nil
+}
+
+func (i *ins) timeValue(field Field, value interface{}) (time.Time, error) {
+	if val, ok := value.(time.Time); ok {
+		return val, nil
+	}
+
+	if val, ok := value.(string); ok {
+		for _, layout := range dateLayouts {
+			t, err := time.Parse(layout, val)
+			if err == nil {
+				return t, nil
+			}
+		}
+
+		return time.Time{}, fmt.Errorf("field %s requires ISO-8601 date, cannot parse '%s'", field.Name, val)
+	}
+
+	return time.Time{}, fmt.Errorf("field %s requires time.Time or ISO-8601 string value in data map", field.Name)
+}
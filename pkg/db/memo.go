@@ -0,0 +1,221 @@
+package localdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	filemanager "godb/pkg/file"
+	"os"
+)
+
+const (
+	memoFileExt = ".dbt"
+	// memoHeaderLength holds the pointer of the first free chunk
+	memoHeaderLength = filemanager.Int64Length
+	// memoChunkHeaderLength is the capacity + the length of the stored value
+	memoChunkHeaderLength = 2 * filemanager.Int64Length
+	// memoMinCapacity leaves room for the next free chunk pointer when the chunk gets freed
+	memoMinCapacity = filemanager.Int64Length
+	memoFreeChunk   = -1
+)
+
+// memo stores the values of memo and blob fields in length prefixed chunks, the record only holds the pointer of the chunk.
+// Pointer 0 (the file header) is an empty value, freed chunks are linked into a free list and reused by the next writes
+type memo struct {
+	filer filemanager.Filer
+	file  *os.File
+}
+
+func (c *CurrentTable) memo() *memo {
+	return &memo{filer: c.filer, file: c.fileHandlers.dbt}
+}
+
+// memoOffsets returns the positions of the memo pointers in the record
+func (c *CurrentTable) memoOffsets() []int {
+	offsets := make([]int, 0)
+	offset := c.fieldDef.nullBitmapSize()
+	for _, field := range c.fieldDef.Fields {
+		if field.isMemo() {
+			offsets = append(offsets, offset)
+		}
+
+		// the sizes are already validated when the table was opened
+		size, _ := field.size()
+		offset += size
+	}
+
+	return offsets
+}
+
+// writeMemoHeader initialises a blank memo file with an empty free list
+func writeMemoHeader(filer filemanager.Filer, fileName string) error {
+	file, err := filer.OpenReadWrite(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return filer.WriteInt64(file, 0, 0)
+}
+
+func (m *memo) read(ptr int64) ([]byte, error) {
+	if ptr == 0 {
+		return []byte{}, nil
+	}
+
+	_, length, err := m.chunkHeader(ptr)
+	if err != nil {
+		return nil, err
+	}
+
+	if length == memoFreeChunk {
+		return nil, fmt.Errorf("memo chunk %d is already freed", ptr)
+	}
+
+	if length == 0 {
+		return []byte{}, nil
+	}
+
+	buf, eof, err := m.filer.ReadBytes(m.file, ptr+memoChunkHeaderLength, int(length))
+	if err != nil {
+		return nil, err
+	}
+
+	if eof {
+		return nil, fmt.Errorf("memo chunk %d is beyond the end of the memo file", ptr)
+	}
+
+	return buf, nil
+}
+
+// write stores the data, the chunk of ptr is rewritten in place when it fits, otherwise it gets freed. Returns the pointer of the data
+func (m *memo) write(ptr int64, data []byte) (int64, error) {
+	if ptr != 0 {
+		capacity, _, err := m.chunkHeader(ptr)
+		if err != nil {
+			return 0, err
+		}
+
+		if len(data) > 0 && int64(len(data)) <= capacity {
+			return ptr, m.writeChunk(ptr, capacity, data)
+		}
+
+		err = m.free(ptr)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if len(data) == 0 {
+		return 0, nil
+	}
+
+	ptr, capacity, err := m.allocate(len(data))
+	if err != nil {
+		return 0, err
+	}
+
+	if ptr != 0 {
+		return ptr, m.writeChunk(ptr, capacity, data)
+	}
+
+	capacity = int64(max(len(data), memoMinCapacity))
+	buf := make([]byte, memoChunkHeaderLength+capacity)
+	binary.LittleEndian.PutUint64(buf, uint64(capacity))
+	binary.LittleEndian.PutUint64(buf[filemanager.Int64Length:], uint64(len(data)))
+	copy(buf[memoChunkHeaderLength:], data)
+
+	return m.filer.AppendBytes(m.file, buf)
+}
+
+// free puts the chunk on the head of the free list
+func (m *memo) free(ptr int64) error {
+	if ptr == 0 {
+		return nil
+	}
+
+	head, err := m.readPtr(0)
+	if err != nil {
+		return err
+	}
+
+	err = m.filer.WriteInt64(m.file, ptr+filemanager.Int64Length, memoFreeChunk)
+	if err != nil {
+		return err
+	}
+
+	err = m.filer.WriteInt64(m.file, ptr+memoChunkHeaderLength, head)
+	if err != nil {
+		return err
+	}
+
+	return m.filer.WriteInt64(m.file, 0, ptr)
+}
+
+// allocate unlinks the first free chunk large enough for the size, returns 0 pointer if there is none
+func (m *memo) allocate(size int) (int64, int64, error) {
+	// The header is the previous link of the first free chunk
+	prevLink := int64(0)
+	ptr, err := m.readPtr(prevLink)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for ptr != 0 {
+		capacity, _, err := m.chunkHeader(ptr)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		next, err := m.readPtr(ptr + memoChunkHeaderLength)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if capacity >= int64(size) {
+			return ptr, capacity, m.filer.WriteInt64(m.file, prevLink, next)
+		}
+
+		prevLink = ptr + memoChunkHeaderLength
+		ptr = next
+	}
+
+	return 0, 0, nil
+}
+
+func (m *memo) writeChunk(ptr, capacity int64, data []byte) error {
+	buf := make([]byte, memoChunkHeaderLength, memoChunkHeaderLength+len(data))
+	binary.LittleEndian.PutUint64(buf, uint64(capacity))
+	binary.LittleEndian.PutUint64(buf[filemanager.Int64Length:], uint64(len(data)))
+	buf = append(buf, data...)
+
+	return m.filer.WriteBytes(m.file, ptr, buf)
+}
+
+func (m *memo) chunkHeader(ptr int64) (int64, int64, error) {
+	buf, eof, err := m.filer.ReadBytes(m.file, ptr, memoChunkHeaderLength)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if eof {
+		return 0, 0, fmt.Errorf("memo chunk %d is beyond the end of the memo file", ptr)
+	}
+
+	capacity := int64(binary.LittleEndian.Uint64(buf))
+	length := int64(binary.LittleEndian.Uint64(buf[filemanager.Int64Length:]))
+
+	return capacity, length, nil
+}
+
+func (m *memo) readPtr(filePointer int64) (int64, error) {
+	ptr, eof, err := m.filer.ReadInt64(m.file, filePointer)
+	if err != nil {
+		return 0, err
+	}
+
+	if eof {
+		return 0, fmt.Errorf("memo file is corrupted, cannot read pointer at %d", filePointer)
+	}
+
+	return ptr, nil
+}
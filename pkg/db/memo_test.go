@@ -0,0 +1,198 @@
+package localdb
+
+import (
+	filemanager "godb/pkg/file"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type memoTestSuite struct {
+	suite.Suite
+	db Manager
+	ct *CurrentTable
+}
+
+func TestMemoRunner(t *testing.T) {
+	suite.Run(t, new(memoTestSuite))
+}
+
+func (t *memoTestSuite) SetupTest() {
+	err := os.RemoveAll(filemanager.DefaultFolder)
+	if err != nil {
+		panic("Cannot run test, the folder cannot be removed " + err.Error())
+	}
+
+	t.db = New()
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "name", Type: FtText, Length: 10, Indexes: []IndexDef{{Name: "memo_name"}}},
+			{Name: "notes", Type: FtMemo},
+			{Name: "payload", Type: FtBlob},
+		},
+	}
+	tableName := "memo_tests"
+	err = t.db.Create(tableName, tableStruct)
+	if err != nil {
+		panic("Cannot run test, Could not create database " + err.Error())
+	}
+
+	ct, err := t.db.Open(tableName)
+	if err != nil {
+		panic("Cannot open table " + err.Error())
+	}
+
+	t.ct = ct
+}
+
+func (t *memoTestSuite) TearDownTest() {
+	t.ct.Close()
+	t.db = nil
+}
+
+func (t *memoTestSuite) memoFileSize() int64 {
+	stat, err := t.ct.fileHandlers.dbt.Stat()
+	t.Nil(err)
+
+	return stat.Size()
+}
+
+func (t *memoTestSuite) TestInsertAndFetchMemo() {
+	notes := strings.Repeat("long notes ", 100)
+	_, err := t.db.Insert(t.ct, map[string]interface{}{"name": "first", "notes": notes, "payload": []byte{0, 1, 2, 0}})
+	t.Nil(err)
+
+	_, err = t.db.Insert(t.ct, map[string]interface{}{"name": "second", "notes": []byte("bytes"), "payload": "text"})
+	t.Nil(err)
+
+	_, err = t.db.Insert(t.ct, map[string]interface{}{"name": "empty"})
+	t.Nil(err)
+
+	_, err = t.db.Insert(t.ct, map[string]interface{}{"name": "wrong", "notes": 12})
+	t.Error(err)
+
+	res, _, _, err := t.db.Fetch(t.ct, 0)
+	t.Nil(err)
+	t.Equal(notes, res["notes"])
+	t.Equal([]byte{0, 1, 2, 0}, res["payload"])
+
+	res, _, _, err = t.db.Fetch(t.ct, 1)
+	t.Nil(err)
+	t.Equal("bytes", res["notes"])
+	t.Equal([]byte("text"), res["payload"])
+
+	res, _, _, err = t.db.Fetch(t.ct, 2)
+	t.Nil(err)
+	t.Equal("", res["notes"])
+	t.Equal([]byte{}, res["payload"])
+
+	// Reopen reads the memo file again
+	err = t.ct.Close()
+	t.Nil(err)
+	t.ct, err = t.db.Open("memo_tests")
+	t.Nil(err)
+
+	res, err = t.db.Locate(t.ct, "notes", "bytes")
+	t.Nil(err)
+	t.Equal(int64(1), res["_recNo"])
+
+	_, err = t.db.Locate(t.ct, "payload", []byte("text"))
+	t.Error(err)
+}
+
+func (t *memoTestSuite) TestUpdateReusesChunks() {
+	_, err := t.db.Insert(t.ct, map[string]interface{}{"name": "first", "notes": "0123456789", "payload": []byte("blob")})
+	t.Nil(err)
+	size := t.memoFileSize()
+
+	// Fits into the old chunk
+	err = t.db.Update(t.ct, 0, map[string]interface{}{"notes": "short"})
+	t.Nil(err)
+	t.Equal(size, t.memoFileSize())
+
+	// Fields missing from the update keep their memo
+	err = t.db.Update(t.ct, 0, map[string]interface{}{"name": "renamed"})
+	t.Nil(err)
+	t.Equal(size, t.memoFileSize())
+
+	res, _, _, err := t.db.Fetch(t.ct, 0)
+	t.Nil(err)
+	t.Equal("short", res["notes"])
+	t.Equal([]byte("blob"), res["payload"])
+
+	// Grows, the old chunk is freed and reused by the next insert
+	err = t.db.Update(t.ct, 0, map[string]interface{}{"notes": strings.Repeat("x", 100)})
+	t.Nil(err)
+	size = t.memoFileSize()
+
+	_, err = t.db.Insert(t.ct, map[string]interface{}{"name": "second", "notes": "reused"})
+	t.Nil(err)
+	t.Equal(size, t.memoFileSize())
+
+	res, _, _, err = t.db.Fetch(t.ct, 0)
+	t.Nil(err)
+	t.Equal(strings.Repeat("x", 100), res["notes"])
+
+	res, _, _, err = t.db.Fetch(t.ct, 1)
+	t.Nil(err)
+	t.Equal("reused", res["notes"])
+}
+
+func (t *memoTestSuite) TestDeleteRecallPackAndZap() {
+	for _, notes := range []string{"first notes", "second notes", "third notes"} {
+		_, err := t.db.Insert(t.ct, map[string]interface{}{"name": "row", "notes": notes})
+		t.Nil(err)
+	}
+
+	err := t.db.Delete(t.ct, 0)
+	t.Nil(err)
+	err = t.db.Delete(t.ct, 1)
+	t.Nil(err)
+
+	// Deleted records keep their memo until pack, so they can be recalled
+	err = t.db.Recall(t.ct, 1)
+	t.Nil(err)
+
+	res, _, _, err := t.db.Fetch(t.ct, 1)
+	t.Nil(err)
+	t.Equal("second notes", res["notes"])
+
+	size := t.memoFileSize()
+	stat, err := t.db.Pack(t.ct)
+	t.Nil(err)
+	t.Equal(int64(2), stat.RecordCount)
+	t.Less(t.memoFileSize(), size)
+	t.Equal(int64(t.ct.recordSize+filemanager.PointerRecordLength)+size-t.memoFileSize(), stat.ReclaimedBytes)
+
+	res, _, _, err = t.db.Fetch(t.ct, 0)
+	t.Nil(err)
+	t.Equal("second notes", res["notes"])
+
+	res, _, _, err = t.db.Fetch(t.ct, 1)
+	t.Nil(err)
+	t.Equal("third notes", res["notes"])
+
+	err = t.db.Zap(t.ct)
+	t.Nil(err)
+	t.Equal(int64(memoHeaderLength), t.memoFileSize())
+
+	_, err = t.db.Insert(t.ct, map[string]interface{}{"name": "row", "notes": "after zap"})
+	t.Nil(err)
+
+	res, _, _, err = t.db.Fetch(t.ct, 0)
+	t.Nil(err)
+	t.Equal("after zap", res["notes"])
+}
+
+func (t *memoTestSuite) TestMemoCannotBeIndexed() {
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "notes", Type: FtMemo, Indexes: []IndexDef{{Name: "memo_notes"}}},
+		},
+	}
+
+	err := t.db.Create("memo_index_tests", tableStruct)
+	t.Error(err)
+}
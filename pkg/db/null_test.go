@@ -0,0 +1,170 @@
+package localdb
+
+import (
+	filemanager "godb/pkg/file"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type nullTestSuite struct {
+	suite.Suite
+	db Manager
+	ct *CurrentTable
+}
+
+func TestNullRunner(t *testing.T) {
+	suite.Run(t, new(nullTestSuite))
+}
+
+func (t *nullTestSuite) SetupTest() {
+	err := os.RemoveAll(filemanager.DefaultFolder)
+	if err != nil {
+		panic("Cannot run test, the folder cannot be removed " + err.Error())
+	}
+
+	t.db = New()
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "name", Type: FtText, Length: 10, Nullable: true, Indexes: []IndexDef{{Name: "null_name"}}},
+			{Name: "age", Type: FtInt, Nullable: true, Indexes: []IndexDef{
+				{Name: "null_age"},
+				{Name: "null_age_skip", SkipNulls: true},
+			}},
+			{Name: "active", Type: FtBool},
+			{Name: "notes", Type: FtMemo, Nullable: true},
+		},
+	}
+	tableName := "null_tests"
+	err = t.db.Create(tableName, tableStruct)
+	if err != nil {
+		panic("Cannot run test, Could not create database " + err.Error())
+	}
+
+	ct, err := t.db.Open(tableName)
+	if err != nil {
+		panic("Cannot open table " + err.Error())
+	}
+
+	t.ct = ct
+
+	rows := []map[string]interface{}{
+		{"name": "john", "age": int64(30), "active": true, "notes": "some notes"},
+		{"name": "jane", "active": true},
+		{"name": nil, "age": int64(20), "active": false, "notes": nil},
+		{"age": nil, "active": false},
+		{"name": "bob", "age": int64(10), "active": true},
+	}
+	for _, row := range rows {
+		_, err = t.db.Insert(t.ct, row)
+		if err != nil {
+			panic("Cannot insert test data " + err.Error())
+		}
+	}
+}
+
+func (t *nullTestSuite) TearDownTest() {
+	t.ct.Close()
+	t.db = nil
+}
+
+func (t *nullTestSuite) walk() []int64 {
+	recNos := make([]int64, 0)
+	err := t.db.First(t.ct)
+	t.Nil(err)
+
+	for {
+		recNos = append(recNos, t.ct.CursorPos())
+		eof, err := t.db.Next(t.ct)
+		t.Nil(err)
+		if eof {
+			return recNos
+		}
+	}
+}
+
+func (t *nullTestSuite) TestFetchReturnsNil() {
+	res, _, _, err := t.db.Fetch(t.ct, 0)
+	t.Nil(err)
+	t.Equal("john", res["name"])
+	t.Equal(int64(30), res["age"])
+	t.Equal("some notes", res["notes"])
+
+	res, _, _, err = t.db.Fetch(t.ct, 1)
+	t.Nil(err)
+	t.Equal("jane", res["name"])
+	t.Nil(res["age"])
+	t.Nil(res["notes"])
+	t.Equal(true, res["active"])
+
+	res, _, _, err = t.db.Fetch(t.ct, 3)
+	t.Nil(err)
+	t.Nil(res["name"])
+	t.Nil(res["age"])
+	t.Equal(false, res["active"])
+
+	// Not nullable fields still require a value
+	_, err = t.db.Insert(t.ct, map[string]interface{}{"name": "nobody"})
+	t.Error(err)
+}
+
+func (t *nullTestSuite) TestIndexSortsNullsFirst() {
+	err := t.db.Use(t.ct, "null_age")
+	t.Nil(err)
+	recNos := t.walk()
+	t.ElementsMatch([]int64{1, 3}, recNos[:2])
+	t.Equal([]int64{4, 2, 0}, recNos[2:])
+
+	err = t.db.Use(t.ct, "null_age_skip")
+	t.Nil(err)
+	t.Equal([]int64{4, 2, 0}, t.walk())
+
+	err = t.db.Use(t.ct, "null_name")
+	t.Nil(err)
+	recNos = t.walk()
+	t.ElementsMatch([]int64{2, 3}, recNos[:2])
+	t.Equal([]int64{4, 1, 0}, recNos[2:])
+
+	_, err = t.db.Seek(t.ct, "jane")
+	t.Nil(err)
+	t.Equal(int64(1), t.ct.CursorPos())
+
+	_, err = t.db.Seek(t.ct, nil)
+	t.Nil(err)
+	t.Contains([]int64{2, 3}, t.ct.CursorPos())
+
+	res, err := t.db.Locate(t.ct, "name", nil)
+	t.Nil(err)
+	t.Contains([]int64{2, 3}, res["_recNo"])
+}
+
+func (t *nullTestSuite) TestUpdateAndDeleteNulls() {
+	err := t.db.Update(t.ct, 1, map[string]interface{}{"age": int64(40), "notes": "added"})
+	t.Nil(err)
+
+	err = t.db.Update(t.ct, 0, map[string]interface{}{"age": nil, "notes": nil})
+	t.Nil(err)
+
+	res, _, _, err := t.db.Fetch(t.ct, 0)
+	t.Nil(err)
+	t.Nil(res["age"])
+	t.Nil(res["notes"])
+	t.Equal("john", res["name"])
+
+	res, _, _, err = t.db.Fetch(t.ct, 1)
+	t.Nil(err)
+	t.Equal(int64(40), res["age"])
+	t.Equal("added", res["notes"])
+
+	err = t.db.Use(t.ct, "null_age_skip")
+	t.Nil(err)
+	t.Equal([]int64{4, 2, 1}, t.walk())
+
+	err = t.db.Delete(t.ct, 3)
+	t.Nil(err)
+
+	err = t.db.Use(t.ct, "null_age")
+	t.Nil(err)
+	t.Equal([]int64{0, 4, 2, 1}, t.walk())
+}
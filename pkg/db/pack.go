@@ -0,0 +1,329 @@
+package localdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	filemanager "godb/pkg/file"
+	"os"
+)
+
+const packFileExt = ".pck"
+
+// PackStat reports the result of a pack
+type PackStat struct {
+	RecordCount    int64
+	ReclaimedRows  int64
+	ReclaimedBytes int64
+}
+
+func newPacker() packer {
+	filer := filemanager.New()
+	return &pck{
+		filer:    filer,
+		inserter: &ins{filer: filer},
+		fetcher:  &fetch{filer: filer},
+	}
+}
+
+type packer interface {
+	Pack(c *CurrentTable) (*PackStat, error)
+}
+
+type pck struct {
+	filer    filemanager.Filer
+	inserter *ins
+	fetcher  *fetch
+}
+
+// Pack writes the non deleted records into new data and pointer files, swaps them in and rebuilds the indexes
+func (p *pck) Pack(c *CurrentTable) (*PackStat, error) {
+	p.inserter.CurrentTable = c
+
+	stat, err := p.writePackedFiles(c)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.swapFiles(c)
+	if err != nil {
+		return nil, err
+	}
+
+	c.recordCount = stat.RecordCount
+	c.recordNo = 0
+
+	err = p.rebuildIndexes(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return stat, nil
+}
+
+func (p *pck) writePackedFiles(c *CurrentTable) (*PackStat, error) {
+	datFile, err := p.createPackFile(c.tableName + dataFileExt)
+	if err != nil {
+		return nil, err
+	}
+	defer datFile.Close()
+
+	rptFile, err := p.createPackFile(c.tableName + recordPointerFileExt)
+	if err != nil {
+		return nil, err
+	}
+	defer rptFile.Close()
+
+	datWriter := bufio.NewWriter(datFile)
+	rptWriter := bufio.NewWriter(rptFile)
+	stat := &PackStat{}
+	var datFilePointer int64
+
+	var memoPacker *memoPack
+	if c.fileHandlers.dbt != nil {
+		memoPacker, err = p.newMemoPack(c)
+		if err != nil {
+			return nil, err
+		}
+		defer memoPacker.file.Close()
+	}
+
+	cursorCount := c.CursorCount()
+	for recNo := int64(0); recNo < cursorCount; recNo++ {
+		record, _, eof, isDeleted, err := p.fetcher.readRecord(c, recNo)
+		if err != nil {
+			return nil, err
+		}
+
+		if eof {
+			break
+		}
+
+		if isDeleted {
+			stat.ReclaimedRows++
+			stat.ReclaimedBytes += int64(c.recordSize) + filemanager.PointerRecordLength
+			continue
+		}
+
+		if memoPacker != nil {
+			err = memoPacker.copyMemos(record)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		_, err = datWriter.Write(record)
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, filemanager.Int64Length)
+		binary.LittleEndian.PutUint64(buf, uint64(datFilePointer))
+		buf = append(buf, 0) // not deleted flag
+
+		_, err = rptWriter.Write(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		datFilePointer += int64(c.recordSize)
+		stat.RecordCount++
+	}
+
+	err = datWriter.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	err = rptWriter.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	err = datFile.Sync()
+	if err != nil {
+		return nil, err
+	}
+
+	if memoPacker != nil {
+		reclaimed, err := memoPacker.finish()
+		if err != nil {
+			return nil, err
+		}
+		stat.ReclaimedBytes += reclaimed
+	}
+
+	return stat, rptFile.Sync()
+}
+
+func (p *pck) createPackFile(fileName string) (*os.File, error) {
+	packFileName := fileName + packFileExt
+	err := p.filer.CreateBlankFileOverwriteIfExist(packFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.filer.OpenReadWrite(packFileName)
+}
+
+// swapFiles closes the table files, renames the packed files over them and reopens them
+func (p *pck) swapFiles(c *CurrentTable) error {
+	err := c.fileHandlers.dat.Close()
+	if err != nil {
+		return err
+	}
+
+	err = c.fileHandlers.rpt.Close()
+	if err != nil {
+		return err
+	}
+
+	fileNames := []string{c.tableName + dataFileExt, c.tableName + recordPointerFileExt}
+	if c.fileHandlers.dbt != nil {
+		err = c.fileHandlers.dbt.Close()
+		if err != nil {
+			return err
+		}
+		fileNames = append(fileNames, c.tableName+memoFileExt)
+	}
+
+	for _, fileName := range fileNames {
+		fullPath := p.filer.GetFullFilePath(fileName)
+		err := os.Rename(fullPath+packFileExt, fullPath)
+		if err != nil {
+			return fmt.Errorf("cannot replace %s with the packed file: %s", fileName, err.Error())
+		}
+	}
+
+	err = c.openDatFile()
+	if err != nil {
+		return err
+	}
+
+	err = c.openPointerFile()
+	if err != nil {
+		return err
+	}
+
+	if c.fileHandlers.dbt != nil {
+		return c.openMemoFile()
+	}
+
+	return nil
+}
+
+// memoPack copies the memo chunks of the packed records to a new memo file without any free space
+type memoPack struct {
+	memo        *memo
+	file        *os.File
+	writer      *bufio.Writer
+	offsets     []int
+	filePointer int64
+}
+
+func (p *pck) newMemoPack(c *CurrentTable) (*memoPack, error) {
+	file, err := p.createPackFile(c.tableName + memoFileExt)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &memoPack{
+		memo:        c.memo(),
+		file:        file,
+		writer:      bufio.NewWriter(file),
+		offsets:     c.memoOffsets(),
+		filePointer: memoHeaderLength,
+	}
+
+	// Empty free list
+	_, err = m.writer.Write(make([]byte, memoHeaderLength))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// copyMemos writes the memo values of the record to the new memo file and points the record to them
+func (m *memoPack) copyMemos(record []byte) error {
+	for _, offset := range m.offsets {
+		data, err := m.memo.read(int64(binary.LittleEndian.Uint64(record[offset:])))
+		if err != nil {
+			return err
+		}
+
+		if len(data) == 0 {
+			binary.LittleEndian.PutUint64(record[offset:], 0)
+			continue
+		}
+
+		capacity := max(len(data), memoMinCapacity)
+		buf := make([]byte, memoChunkHeaderLength+capacity)
+		binary.LittleEndian.PutUint64(buf, uint64(capacity))
+		binary.LittleEndian.PutUint64(buf[filemanager.Int64Length:], uint64(len(data)))
+		copy(buf[memoChunkHeaderLength:], data)
+
+		_, err = m.writer.Write(buf)
+		if err != nil {
+			return err
+		}
+
+		binary.LittleEndian.PutUint64(record[offset:], uint64(m.filePointer))
+		m.filePointer += int64(len(buf))
+	}
+
+	return nil
+}
+
+// finish flushes the new memo file, returns the bytes reclaimed from the old one
+func (m *memoPack) finish() (int64, error) {
+	err := m.writer.Flush()
+	if err != nil {
+		return 0, err
+	}
+
+	err = m.file.Sync()
+	if err != nil {
+		return 0, err
+	}
+
+	stat, err := m.memo.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	return stat.Size() - m.filePointer, nil
+}
+
+func (p *pck) rebuildIndexes(c *CurrentTable) error {
+	for _, index := range c.indexes {
+		err := index.index.Reset()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(c.indexes) == 0 {
+		return nil
+	}
+
+	for recNo := int64(0); recNo < c.recordCount; recNo++ {
+		record, _, _, _, err := p.fetcher.readRecord(c, recNo)
+		if err != nil {
+			return err
+		}
+
+		data, err := p.fetcher.mapBufferToData(c, record)
+		if err != nil {
+			return err
+		}
+
+		err = p.inserter.addToIndexIfIndexed(data, recNo)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
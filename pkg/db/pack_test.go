@@ -0,0 +1,126 @@
+package localdb
+
+import (
+	filemanager "godb/pkg/file"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type packTestSuite struct {
+	suite.Suite
+	db Manager
+	ct *CurrentTable
+}
+
+func TestPackRunner(t *testing.T) {
+	suite.Run(t, new(packTestSuite))
+}
+
+func (t *packTestSuite) SetupTest() {
+	err := os.RemoveAll(filemanager.DefaultFolder)
+	if err != nil {
+		panic("Cannot run test, the folder cannot be removed " + err.Error())
+	}
+
+	t.db = New()
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "field_1", Type: FtText, Length: 15, Indexes: []IndexDef{{Name: "pack_field_1"}}},
+			{Name: "field_2", Type: FtInt, Indexes: []IndexDef{{Name: "pack_field_2"}}},
+		},
+	}
+	tableName := "pack_tests"
+	err = t.db.Create(tableName, tableStruct)
+	if err != nil {
+		panic("Cannot run test, Could not create database " + err.Error())
+	}
+
+	ct, err := t.db.Open(tableName)
+	if err != nil {
+		panic("Cannot open table " + err.Error())
+	}
+
+	t.ct = ct
+
+	for i := 0; i < 10; i++ {
+		_, err = t.db.Insert(t.ct, map[string]interface{}{
+			"field_1": "data " + strconv.Itoa(i),
+			"field_2": int64(i),
+		})
+		if err != nil {
+			panic("Cannot insert test data " + err.Error())
+		}
+	}
+}
+
+func (t *packTestSuite) TearDownTest() {
+	t.ct.Close()
+	t.db = nil
+}
+
+func (t *packTestSuite) TestPackRemovesDeletedRecords() {
+	for _, recNo := range []int64{0, 4, 9} {
+		err := t.db.Delete(t.ct, recNo)
+		t.Nil(err)
+	}
+
+	stat, err := t.db.Pack(t.ct)
+	t.Nil(err)
+	t.Equal(int64(7), stat.RecordCount)
+	t.Equal(int64(3), stat.ReclaimedRows)
+	t.Equal(int64(3*(t.ct.recordSize+filemanager.PointerRecordLength)), stat.ReclaimedBytes)
+
+	rc, err := t.db.RecCount(t.ct)
+	t.Nil(err)
+	t.Equal(int64(7), rc)
+	t.FileExists(filemanager.DefaultFolder + "/pack_tests" + dataFileExt)
+	t.NoFileExists(filemanager.DefaultFolder + "/pack_tests" + dataFileExt + packFileExt)
+
+	expected := []string{"data 1", "data 2", "data 3", "data 5", "data 6", "data 7", "data 8"}
+	for i, value := range expected {
+		res, _, isDeleted, err := t.db.Fetch(t.ct, int64(i))
+		t.Nil(err)
+		t.False(isDeleted)
+		t.Equal(value, res["field_1"])
+	}
+
+	_, eof, _, err := t.db.Fetch(t.ct, 7)
+	t.Nil(err)
+	t.True(eof)
+
+	err = t.db.Use(t.ct, "pack_field_1")
+	t.Nil(err)
+
+	res, err := t.db.Locate(t.ct, "field_1", "data 5")
+	t.Nil(err)
+	t.Equal(int64(3), res["_recNo"])
+
+	_, err = t.db.Locate(t.ct, "field_1", "data 4")
+	t.ErrorIs(err, errNotFound)
+
+	err = t.db.Use(t.ct, "pack_field_2")
+	t.Nil(err)
+
+	err = t.db.Last(t.ct)
+	t.Nil(err)
+	t.Equal(int64(6), t.ct.CursorPos())
+
+	// Table keeps working after the pack
+	_, err = t.db.Insert(t.ct, map[string]interface{}{"field_1": "data 10", "field_2": int64(10)})
+	t.Nil(err)
+
+	res, _, _, err = t.db.Fetch(t.ct, 7)
+	t.Nil(err)
+	t.Equal("data 10", res["field_1"])
+}
+
+func (t *packTestSuite) TestPackWithoutDeletedRecords() {
+	stat, err := t.db.Pack(t.ct)
+	t.Nil(err)
+	t.Equal(int64(10), stat.RecordCount)
+	t.Equal(int64(0), stat.ReclaimedRows)
+	t.Equal(int64(0), stat.ReclaimedBytes)
+}
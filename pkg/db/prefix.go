@@ -0,0 +1,74 @@
+package localdb
+
+import (
+	"bytes"
+	"fmt"
+	"godb/pkg/btree"
+	"iter"
+)
+
+// SeekPrefix moves the cursor to the first record of the text index in use with a key starting with the prefix and
+// reports if there was one. When not found the cursor is placed like after Seek
+func (f *fetch) SeekPrefix(c *CurrentTable, prefix string) (bool, error) {
+	start, prefixKey, err := f.prefixKeys(c, prefix)
+	if err != nil {
+		return false, err
+	}
+
+	recNo, key, _, err := c.userIndex.Search(start)
+	if err != nil {
+		return false, err
+	}
+
+	return seekResult(c, recNo, key, start, key != nil && bytes.HasPrefix(*key, prefixKey)), nil
+}
+
+// ScanPrefix returns the records of the text index in use with a key starting with the prefix, in the order of the
+// index, at most limit records if it is not 0
+func (f *fetch) ScanPrefix(c *CurrentTable, prefix string, limit int) (iter.Seq2[map[string]interface{}, error], error) {
+	start, prefixKey, err := f.prefixKeys(c, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.scan(c, start, limit, func(key []byte) (bool, bool) {
+		match := bytes.HasPrefix(key, prefixKey)
+
+		return match, match
+	}), nil
+}
+
+// prefixKeys returns the key to search and the prefix of the matching keys. The keys are padded with zeros, so the
+// prefix itself is less than every key starting with it, on a descending index the search starts after the greatest
+// key of the prefix
+func (f *fetch) prefixKeys(c *CurrentTable, prefix string) ([]byte, []byte, error) {
+	err := unordered(c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if c.userIndex == nil {
+		return nil, nil, fmt.Errorf("prefix search only works if index is in use")
+	}
+
+	opts := c.userTableIndex.keyOptions()
+	if opts.KeyType != btree.KeyText {
+		return nil, nil, fmt.Errorf("prefix search only works on text indexes, %s is not one", c.userTableIndex.Name)
+	}
+
+	prefixKey, ok := f.seekKey(c, prefix)
+	if !ok {
+		return nil, nil, fmt.Errorf("prefix %s is not a key of index %s", prefix, c.userTableIndex.Name)
+	}
+
+	if c.userTableIndex.expression != nil {
+		// the text fields are padded with spaces in the expression
+		prefixKey = bytes.TrimRight(prefixKey, " ")
+	}
+
+	if !opts.Descending {
+		return prefixKey, prefixKey, nil
+	}
+
+	return append(bytes.Clone(prefixKey), 0xff), prefixKey, nil
+}
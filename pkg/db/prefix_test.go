@@ -0,0 +1,127 @@
+package localdb
+
+import (
+	filemanager "godb/pkg/file"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type prefixTestSuite struct {
+	suite.Suite
+	db Manager
+	ct *CurrentTable
+}
+
+func TestPrefixRunner(t *testing.T) {
+	suite.Run(t, new(prefixTestSuite))
+}
+
+func (t *prefixTestSuite) SetupTest() {
+	err := os.RemoveAll(filemanager.DefaultFolder)
+	if err != nil {
+		panic("Cannot run test, the folder cannot be removed " + err.Error())
+	}
+
+	t.db = New()
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "name", Type: FtText, Length: 10, Nullable: true, Indexes: []IndexDef{
+				{Name: "prefix_name"},
+				{Name: "prefix_name_desc", Descending: true},
+			}},
+			{Name: "qty", Type: FtReal, Indexes: []IndexDef{{Name: "prefix_qty"}}},
+		},
+		Indexes: []IndexDef{{Name: "prefix_upper", Expression: "UPPER(name)"}},
+	}
+	tableName := "prefix_tests"
+	err = t.db.Create(tableName, tableStruct)
+	if err != nil {
+		panic("Cannot run test, Could not create database " + err.Error())
+	}
+
+	ct, err := t.db.Open(tableName)
+	if err != nil {
+		panic("Cannot open table " + err.Error())
+	}
+
+	t.ct = ct
+
+	for _, name := range []interface{}{"Smith", "smile", "Smit", "Sam", nil, "Smythe", "Smi", "Snow"} {
+		_, err = t.db.Insert(t.ct, map[string]interface{}{"name": name, "qty": 1.0})
+		if err != nil {
+			panic("Cannot insert test data " + err.Error())
+		}
+	}
+}
+
+func (t *prefixTestSuite) TearDownTest() {
+	t.ct.Close()
+	t.db = nil
+}
+
+func (t *prefixTestSuite) scan(index, prefix string, limit int) []interface{} {
+	err := t.db.Use(t.ct, index)
+	t.Nil(err)
+
+	records, err := t.db.ScanPrefix(t.ct, prefix, limit)
+	t.Nil(err)
+
+	names := make([]interface{}, 0)
+	for record, err := range records {
+		t.Nil(err)
+		names = append(names, record["name"])
+	}
+
+	return names
+}
+
+func (t *prefixTestSuite) TestScanPrefix() {
+	t.Equal([]interface{}{"Smi", "Smit", "Smith"}, t.scan("prefix_name", "Smi", 0))
+	t.Equal([]interface{}{"Smi", "Smit"}, t.scan("prefix_name", "Smi", 2))
+	t.Equal([]interface{}{"Smith", "Smit", "Smi"}, t.scan("prefix_name_desc", "Smi", 0))
+	t.Equal([]interface{}{"Smi", "smile", "Smit", "Smith"}, t.scan("prefix_upper", "smi", 0))
+	t.Empty(t.scan("prefix_name", "Smx", 0))
+	t.Len(t.scan("prefix_name", "", 0), 7)
+}
+
+func (t *prefixTestSuite) TestSeekPrefix() {
+	err := t.db.Use(t.ct, "prefix_name")
+	t.Nil(err)
+
+	found, err := t.db.SeekPrefix(t.ct, "Smy")
+	t.Nil(err)
+	t.True(found)
+	t.Equal(int64(5), t.ct.CursorPos())
+
+	eof, err := t.db.Next(t.ct)
+	t.Nil(err)
+	t.False(eof)
+	t.Equal(int64(7), t.ct.CursorPos())
+
+	found, err = t.db.SeekPrefix(t.ct, "Sn")
+	t.Nil(err)
+	t.True(found)
+
+	found, err = t.db.SeekPrefix(t.ct, "Sma")
+	t.Nil(err)
+	t.False(found)
+	t.True(t.ct.Eof())
+
+	t.ct.SetSoftSeek(true)
+	found, err = t.db.SeekPrefix(t.ct, "Sma")
+	t.Nil(err)
+	t.False(found)
+	t.Equal(int64(6), t.ct.CursorPos())
+}
+
+func (t *prefixTestSuite) TestInvalidPrefix() {
+	_, err := t.db.SeekPrefix(t.ct, "S")
+	t.EqualError(err, "prefix search only works if index is in use")
+
+	err = t.db.Use(t.ct, "prefix_qty")
+	t.Nil(err)
+	_, err = t.db.ScanPrefix(t.ct, "1", 0)
+	t.EqualError(err, "prefix search only works on text indexes, prefix_qty is not one")
+}
@@ -0,0 +1,126 @@
+package localdb
+
+import (
+	"fmt"
+	"godb/pkg/btree"
+	"iter"
+)
+
+// RangeOpts sets the bounds and the size of a range scan
+type RangeOpts struct {
+	ExcludeFrom bool // the records of the from key are skipped
+	ExcludeTo   bool // the records of the to key are skipped
+	Limit       int  // maximal number of records, 0 is no limit
+}
+
+// Range returns the records of the index in use from the from key to the to key, in the order of the index (a
+// descending index walks from the greater key to the smaller one). A nil bound leaves the range open on that side.
+// The iteration moves the cursor of the table and stops as soon as the to key is passed
+func (f *fetch) Range(c *CurrentTable, from, to interface{}, opts RangeOpts) (iter.Seq2[map[string]interface{}, error], error) {
+	err := unordered(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.userIndex == nil {
+		return nil, fmt.Errorf("range only works if index is in use")
+	}
+
+	fromKey, err := f.rangeKey(c, from)
+	if err != nil {
+		return nil, err
+	}
+
+	toKey, err := f.rangeKey(c, to)
+	if err != nil {
+		return nil, err
+	}
+
+	keyOpts := c.userTableIndex.keyOptions()
+
+	return f.scan(c, fromKey, opts.Limit, func(key []byte) (bool, bool) {
+		if !inRange(key, toKey, keyOpts, opts.ExcludeTo) {
+			return false, false
+		}
+
+		return fromKey == nil || inRange(fromKey, key, keyOpts, opts.ExcludeFrom), true
+	}), nil
+}
+
+// scan iterates over the records of the index in use from the start key, or from the first key if nil. The check of
+// the key reports if the record is returned and if the scan goes on
+func (f *fetch) scan(c *CurrentTable, start []byte, limit int, check func(key []byte) (bool, bool)) iter.Seq2[map[string]interface{}, error] {
+	index := c.userIndex
+
+	return func(yield func(map[string]interface{}, error) bool) {
+		var ptr int64
+		var key *[]byte
+		var eof bool
+		var err error
+		if start == nil {
+			ptr, key, err = index.First()
+		} else {
+			ptr, key, _, err = index.Search(start)
+		}
+
+		for count := 0; limit == 0 || count < limit; {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if eof || key == nil {
+				return
+			}
+
+			include, more := check(*key)
+			if !more {
+				return
+			}
+
+			if include {
+				val, eof, isDeleted, err := f.Fetch(c, ptr)
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+
+				if !eof && !isDeleted {
+					c.recordNo = ptr
+					count++
+					if !yield(val, nil) {
+						return
+					}
+				}
+			}
+
+			ptr, key, eof, err = index.Next()
+		}
+	}
+}
+
+// rangeKey converts the bound of the range to a key of the index in use, nil is an open bound
+func (f *fetch) rangeKey(c *CurrentTable, value interface{}) ([]byte, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	key, ok := f.seekKey(c, value)
+	if !ok {
+		return nil, fmt.Errorf("range bound %v is not a key of index %s", value, c.userTableIndex.Name)
+	}
+
+	return key, nil
+}
+
+// inRange reports if the key is before the bound in the order of the index, or equal to it when not excluded.
+// A nil bound is open
+func inRange(key, bound []byte, opts btree.Options, exclude bool) bool {
+	if bound == nil {
+		return true
+	}
+
+	result := btree.Compare(key, bound, opts)
+
+	return result < 0 || (result == 0 && !exclude)
+}
@@ -0,0 +1,116 @@
+package localdb
+
+import (
+	filemanager "godb/pkg/file"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type rangeTestSuite struct {
+	suite.Suite
+	db Manager
+	ct *CurrentTable
+}
+
+func TestRangeRunner(t *testing.T) {
+	suite.Run(t, new(rangeTestSuite))
+}
+
+func (t *rangeTestSuite) SetupTest() {
+	err := os.RemoveAll(filemanager.DefaultFolder)
+	if err != nil {
+		panic("Cannot run test, the folder cannot be removed " + err.Error())
+	}
+
+	t.db = New()
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "name", Type: FtText, Length: 10, Indexes: []IndexDef{{Name: "range_name"}}},
+			{Name: "price", Type: FtReal, Indexes: []IndexDef{{Name: "range_price"}, {Name: "range_price_desc", Descending: true}}},
+		},
+	}
+	tableName := "range_tests"
+	err = t.db.Create(tableName, tableStruct)
+	if err != nil {
+		panic("Cannot run test, Could not create database " + err.Error())
+	}
+
+	ct, err := t.db.Open(tableName)
+	if err != nil {
+		panic("Cannot open table " + err.Error())
+	}
+
+	t.ct = ct
+
+	for x, name := range []string{"eve", "bob", "dan", "amy", "cid", "fay", "gus"} {
+		_, err = t.db.Insert(t.ct, map[string]interface{}{"name": name, "price": float64(x) * 10})
+		if err != nil {
+			panic("Cannot insert test data " + err.Error())
+		}
+	}
+}
+
+func (t *rangeTestSuite) TearDownTest() {
+	t.ct.Close()
+	t.db = nil
+}
+
+func (t *rangeTestSuite) names(index string, from, to interface{}, opts RangeOpts) []string {
+	err := t.db.Use(t.ct, index)
+	t.Nil(err)
+
+	records, err := t.db.Range(t.ct, from, to, opts)
+	t.Nil(err)
+
+	names := make([]string, 0)
+	for record, err := range records {
+		t.Nil(err)
+		names = append(names, record["name"].(string))
+	}
+
+	return names
+}
+
+func (t *rangeTestSuite) TestBounds() {
+	t.Equal([]string{"bob", "cid", "dan"}, t.names("range_name", "bob", "dan", RangeOpts{}))
+	t.Equal([]string{"cid"}, t.names("range_name", "bob", "dan", RangeOpts{ExcludeFrom: true, ExcludeTo: true}))
+	t.Equal([]string{"cid"}, t.names("range_name", "c", "d", RangeOpts{}))
+	t.Equal([]string{"amy", "bob"}, t.names("range_name", nil, "bz", RangeOpts{}))
+	t.Equal([]string{"fay", "gus"}, t.names("range_name", "f", nil, RangeOpts{}))
+	t.Empty(t.names("range_name", "x", nil, RangeOpts{}))
+	t.Empty(t.names("range_name", "dan", "cid", RangeOpts{}))
+
+	t.Equal([]string{"dan", "amy", "cid"}, t.names("range_price", 20.0, 40.0, RangeOpts{}))
+	t.Equal([]string{"amy", "cid"}, t.names("range_price", 20.0, 40.0, RangeOpts{ExcludeFrom: true}))
+}
+
+func (t *rangeTestSuite) TestDescendingLimitAndDeleted() {
+	t.Equal([]string{"cid", "amy", "dan"}, t.names("range_price_desc", 40.0, 20.0, RangeOpts{}))
+	t.Equal([]string{"gus", "fay"}, t.names("range_price_desc", nil, nil, RangeOpts{Limit: 2}))
+
+	err := t.db.Delete(t.ct, 3)
+	t.Nil(err)
+	t.Equal([]string{"dan", "cid"}, t.names("range_price", 20.0, 40.0, RangeOpts{}))
+
+	// The cursor stays on the last returned record
+	records, err := t.db.Range(t.ct, 10.0, nil, RangeOpts{})
+	t.Nil(err)
+	for record := range records {
+		if record["name"] == "dan" {
+			break
+		}
+	}
+	t.Equal(int64(2), t.ct.CursorPos())
+}
+
+func (t *rangeTestSuite) TestInvalidRange() {
+	_, err := t.db.Range(t.ct, "a", "b", RangeOpts{})
+	t.EqualError(err, "range only works if index is in use")
+
+	err = t.db.Use(t.ct, "range_price")
+	t.Nil(err)
+	_, err = t.db.Range(t.ct, "ten", nil, RangeOpts{})
+	t.Error(err)
+}
@@ -0,0 +1,137 @@
+package localdb
+
+import (
+	"fmt"
+	"godb/pkg/btree"
+	"godb/pkg/hash"
+	bintree "godb/pkg/index"
+	"sync"
+)
+
+// Index maintains the keys of a table index, the implementation is selected by IndexDef.Type
+type Index interface {
+	Insert(key []byte, recNo int64) error
+	Delete(key []byte, recNo int64) error
+	Exists(key []byte) (bool, error)
+	Reset() error
+	Close() error
+}
+
+// OrderedIndex is an index keeping the order of the keys, only these can be used for seek and navigation
+type OrderedIndex interface {
+	Index
+	First() (int64, *[]byte, error)
+	Last() (int64, *[]byte, error)
+	Search(key []byte) (int64, *[]byte, bool, error)
+	Next() (int64, *[]byte, bool, error)
+	Prev() (int64, *[]byte, bool, error)
+}
+
+// CursorIndex is an ordered index giving cursors, indexes with their own navigation on the same keys, Cursor needs it
+type CursorIndex interface {
+	OrderedIndex
+	NewCursor() OrderedIndex
+}
+
+// LookupIndex is an index without order finding the records of equal keys, Locate uses it (e.g. hash)
+type LookupIndex interface {
+	Index
+	Search(key []byte) ([]int64, error)
+}
+
+// IndexSpec describes the index opened by the factory
+type IndexSpec struct {
+	Name    string        // name of the index, the file name without extension
+	KeySize int           // length of the keys, including the null flag of the nullable keys
+	Options btree.Options // type, null flag and order of the keys
+}
+
+// IndexFactory opens (or creates) the index of the spec
+type IndexFactory func(spec IndexSpec) (Index, error)
+
+var (
+	indexFactories = map[string]IndexFactory{
+		"":                  openBTree,
+		IndexTypeUnique:     openBTree,
+		IndexTypeHash:       openHash,
+		IndexTypeBinaryTree: openBinaryTree,
+	}
+	indexFactoriesLock sync.RWMutex
+)
+
+// RegisterIndex registers the factory of an index type, tables select it with IndexDef.Type. A registered type is
+// replaced
+func RegisterIndex(indexType string, factory IndexFactory) {
+	indexFactoriesLock.Lock()
+	defer indexFactoriesLock.Unlock()
+
+	indexFactories[indexType] = factory
+}
+
+// indexFactory returns the factory of the index type
+func indexFactory(indexType string) (IndexFactory, error) {
+	indexFactoriesLock.RLock()
+	defer indexFactoriesLock.RUnlock()
+
+	factory, ok := indexFactories[indexType]
+	if !ok {
+		return nil, fmt.Errorf("unknown index type %s", indexType)
+	}
+
+	return factory, nil
+}
+
+func openBTree(spec IndexSpec) (Index, error) {
+	// the btree adds the null flag to the length of the nullable keys
+	size := spec.KeySize
+	if spec.Options.Nullable {
+		size--
+	}
+
+	tree, err := btree.NewWithOptions(spec.Name, size, spec.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	return bTree{BTree: tree}, nil
+}
+
+func openHash(spec IndexSpec) (Index, error) {
+	return hash.New(spec.Name, spec.KeySize)
+}
+
+func openBinaryTree(spec IndexSpec) (Index, error) {
+	tree, err := bintree.NewWithOptions(spec.Name, spec.KeySize, spec.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	return binaryTree{Indexer: tree, keySize: spec.KeySize}, nil
+}
+
+// bTree gives the cursors of the btree
+type bTree struct {
+	btree.BTree
+}
+
+func (b bTree) NewCursor() OrderedIndex {
+	return b.BTree.Cursor()
+}
+
+// binaryTree pads the keys to the size of the binary tree, the keys of the nullable and the expression indexes can be
+// shorter
+type binaryTree struct {
+	bintree.Indexer
+	keySize int
+}
+
+func (b binaryTree) Insert(key []byte, recNo int64) error {
+	padded := make([]byte, b.keySize)
+	copy(padded, key)
+
+	return b.Indexer.Insert(padded, recNo)
+}
+
+func (b binaryTree) NewCursor() OrderedIndex {
+	return binaryTree{Indexer: b.Indexer.Cursor(), keySize: b.keySize}
+}
@@ -0,0 +1,119 @@
+package localdb
+
+import (
+	filemanager "godb/pkg/file"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// memoryIndex is a lookup index kept in memory, the opened ones are kept by name
+type memoryIndex struct {
+	keys map[string][]int64
+}
+
+var memoryIndexes = make(map[string]*memoryIndex)
+
+func openMemoryIndex(spec IndexSpec) (Index, error) {
+	index := &memoryIndex{keys: make(map[string][]int64)}
+	memoryIndexes[spec.Name] = index
+
+	return index, nil
+}
+
+func (m *memoryIndex) Insert(key []byte, recNo int64) error {
+	m.keys[string(key)] = append(m.keys[string(key)], recNo)
+	return nil
+}
+
+func (m *memoryIndex) Delete(key []byte, recNo int64) error {
+	recNos := m.keys[string(key)]
+	for x, r := range recNos {
+		if r == recNo {
+			m.keys[string(key)] = append(recNos[:x], recNos[x+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (m *memoryIndex) Exists(key []byte) (bool, error) {
+	return len(m.keys[string(key)]) > 0, nil
+}
+
+func (m *memoryIndex) Search(key []byte) ([]int64, error) {
+	return m.keys[string(key)], nil
+}
+
+func (m *memoryIndex) Reset() error {
+	m.keys = make(map[string][]int64)
+	return nil
+}
+
+func (m *memoryIndex) Close() error {
+	return nil
+}
+
+type registryTestSuite struct {
+	suite.Suite
+	db Manager
+}
+
+func TestRegistryRunner(t *testing.T) {
+	suite.Run(t, new(registryTestSuite))
+}
+
+func (t *registryTestSuite) SetupTest() {
+	err := os.RemoveAll(filemanager.DefaultFolder)
+	if err != nil {
+		panic("Cannot run test, the folder cannot be removed " + err.Error())
+	}
+
+	t.db = New()
+	RegisterIndex("memory", openMemoryIndex)
+}
+
+func (t *registryTestSuite) TestRegisteredIndex() {
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "code", Type: FtText, Length: 10, Indexes: []IndexDef{{Name: "memory_code", Type: "memory", Unique: true}}},
+		},
+	}
+	err := t.db.Create("registry_tests", tableStruct)
+	t.Nil(err)
+
+	ct, err := t.db.Open("registry_tests")
+	t.Nil(err)
+	defer ct.Close()
+
+	for _, code := range []string{"a1", "b2", "c3"} {
+		_, err = t.db.Insert(ct, map[string]interface{}{"code": code})
+		t.Nil(err)
+	}
+	t.Len(memoryIndexes["memory_code"].keys, 3)
+
+	_, err = t.db.Insert(ct, map[string]interface{}{"code": "b2"})
+	t.ErrorIs(err, ErrDuplicateKey)
+
+	err = t.db.Update(ct, 1, map[string]interface{}{"code": "d4"})
+	t.Nil(err)
+
+	// Locate uses the lookup index, the index has no order for navigation
+	res, err := t.db.Locate(ct, "code", "d4")
+	t.Nil(err)
+	t.Equal(int64(1), res["_recNo"])
+
+	err = t.db.Use(ct, "memory_code")
+	t.Nil(err)
+	t.EqualError(t.db.First(ct), "index memory_code is a memory index, it has no order for seek and navigation")
+}
+
+func (t *registryTestSuite) TestUnknownType() {
+	tableStruct := &FieldDef{
+		Fields: []Field{{Name: "code", Type: FtText, Length: 10, Indexes: []IndexDef{{Name: "unknown_code", Type: "fulltext"}}}},
+	}
+	err := t.db.Create("registry_unknown", tableStruct)
+	t.EqualError(err, "index unknown_code: unknown index type fulltext")
+}
@@ -0,0 +1,123 @@
+package localdb
+
+import (
+	filemanager "godb/pkg/file"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type seekTestSuite struct {
+	suite.Suite
+	db Manager
+	ct *CurrentTable
+}
+
+func TestSeekRunner(t *testing.T) {
+	suite.Run(t, new(seekTestSuite))
+}
+
+func (t *seekTestSuite) SetupTest() {
+	err := os.RemoveAll(filemanager.DefaultFolder)
+	if err != nil {
+		panic("Cannot run test, the folder cannot be removed " + err.Error())
+	}
+
+	t.db = New()
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "name", Type: FtText, Length: 10, Indexes: []IndexDef{{Name: "seek_name"}}},
+			{Name: "qty", Type: FtInt},
+		},
+		Indexes: []IndexDef{{Name: "seek_name_qty", Fields: []string{"name", "qty"}}},
+	}
+	tableName := "seek_tests"
+	err = t.db.Create(tableName, tableStruct)
+	if err != nil {
+		panic("Cannot run test, Could not create database " + err.Error())
+	}
+
+	ct, err := t.db.Open(tableName)
+	if err != nil {
+		panic("Cannot open table " + err.Error())
+	}
+
+	t.ct = ct
+
+	for x, name := range []string{"dan", "amy", "carl", "bob"} {
+		_, err = t.db.Insert(t.ct, map[string]interface{}{"name": name, "qty": x})
+		if err != nil {
+			panic("Cannot insert test data " + err.Error())
+		}
+	}
+}
+
+func (t *seekTestSuite) TearDownTest() {
+	t.ct.Close()
+	t.db = nil
+}
+
+func (t *seekTestSuite) seek(value interface{}) bool {
+	found, err := t.db.Seek(t.ct, value)
+	t.Nil(err)
+
+	return found
+}
+
+func (t *seekTestSuite) TestFound() {
+	err := t.db.Use(t.ct, "seek_name")
+	t.Nil(err)
+
+	t.True(t.seek("carl"))
+	t.Equal(int64(2), t.ct.CursorPos())
+	t.False(t.ct.Eof())
+
+	// The beginning of a text is enough, like with SET EXACT OFF
+	t.True(t.seek("ca"))
+	t.Equal(int64(2), t.ct.CursorPos())
+
+	t.False(t.seek("cz"))
+	t.True(t.ct.Eof())
+	_, eof, _, err := t.db.FetchCurrent(t.ct)
+	t.Nil(err)
+	t.True(eof)
+
+	err = t.db.Use(t.ct, "seek_name_qty")
+	t.Nil(err)
+
+	t.True(t.seek([]interface{}{"bob"}))
+	t.Equal(int64(3), t.ct.CursorPos())
+	t.True(t.seek([]interface{}{"bob", 3}))
+	t.False(t.seek([]interface{}{"bob", 4}))
+	t.True(t.ct.Eof())
+}
+
+func (t *seekTestSuite) TestSoftSeekAndEof() {
+	err := t.db.Use(t.ct, "seek_name")
+	t.Nil(err)
+
+	t.ct.SetSoftSeek(true)
+	t.False(t.seek("bz"))
+	t.Equal(int64(2), t.ct.CursorPos())
+
+	// Beyond the last key even soft seek goes to the end of file
+	t.False(t.seek("zed"))
+	t.True(t.ct.Eof())
+
+	eof, err := t.db.Next(t.ct)
+	t.Nil(err)
+	t.True(eof)
+	t.True(t.ct.Eof())
+
+	// The previous record of the end of file is the last one
+	bof, err := t.db.Prev(t.ct)
+	t.Nil(err)
+	t.False(bof)
+	t.Equal(int64(0), t.ct.CursorPos())
+
+	bof, err = t.db.Prev(t.ct)
+	t.Nil(err)
+	t.False(bof)
+	t.Equal(int64(2), t.ct.CursorPos())
+}
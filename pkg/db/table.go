@@ -9,24 +9,34 @@ import (
 	"strings"
 )
 
-// todo add index defs maybe for FileDef but probably not due to possible combined indexes
 // todo add transactions
 
-// CurrentTable holds the table info
+// CurrentTable holds the table info and a cursor on it, the position and the index in use
 type CurrentTable struct {
+	*sharedTable
+	recordNo       int64
+	userIndex      OrderedIndex // nil if the index in use has no order
+	userIndexField *Field       // nil if the index in use is a compound or an expression one
+	userTableIndex *tableIndex
+	softSeek       bool // seek not finding the key moves to the next key instead of the end of file
+	cursor         bool // created by Cursor, the files are closed by the table
+}
+
+// sharedTable holds the definition, the files and the indexes of the table, shared by the cursors
+type sharedTable struct {
 	tableName    string
 	fieldDef     FieldDef
-	recordNo     int64
 	recordCount  int64
 	fileHandlers fileHandlers
 	filer        filemanager.Filer
 	recordSize   int
-	userIndex    *btree.BTree
+	indexes      []tableIndex
 }
 
 type fileHandlers struct {
 	dat *os.File
 	rpt *os.File
+	dbt *os.File
 }
 
 // Field types
@@ -35,11 +45,16 @@ const (
 	FtBool
 	FtInt
 	FtReal
+	FtDate
+	FtDateTime
+	FtMemo
+	FtBlob
 )
 
 // FieldDef holds a struct of a new fields
 type FieldDef struct {
-	Fields []Field
+	Fields  []Field
+	Indexes []IndexDef // compound and expression indexes over multiple fields
 }
 
 // Field is the definition of a field
@@ -48,14 +63,118 @@ type Field struct {
 	Name     string
 	Length   int
 	Required bool
+	Nullable bool
+	Default  interface{} // literal value or generator (DefaultNow, DefaultUUID) used when the field is missing on insert
+	Overflow string      // policy of text values longer than the field length, OverflowError if empty
 	Indexes  []IndexDef
 }
 
 // IndexDef of the table index
 type IndexDef struct {
-	Type  string
-	Name  string
-	index *btree.BTree // in future it may go to different indexes or interface and resolve by Type later
+	Type       string // btree if empty, IndexTypeUnique, IndexTypeHash, IndexTypeBinaryTree or a type of RegisterIndex
+	Name       string
+	Fields     []string // fields of the compound index key, in order
+	Expression string   // dBase like key expression, e.g. UPPER(name)+city, instead of Fields
+	Filter     string   // condition of the records in the index (FOR clause), e.g. active .AND. qty > 0
+	Unique     bool     // the index rejects duplicate keys with ErrDuplicateKey
+	SkipNulls  bool     // null values of nullable fields are not added to the index
+	Descending bool     // first returns the greatest key, next walks downward
+	index      Index    // opened by the factory registered for Type
+}
+
+// isMemo reports if the field value is stored in the memo file
+func (f Field) isMemo() bool {
+	return f.Type == FtMemo || f.Type == FtBlob
+}
+
+// size returns the length of the field in the record
+func (f Field) size() (int, error) {
+	switch f.Type {
+	case FtText:
+		return f.Length, nil
+	case FtBool:
+		return 1, nil
+	case FtInt, FtDate, FtDateTime:
+		return filemanager.Int64Length, nil
+	case FtReal:
+		return filemanager.Float64Length, nil
+	case FtMemo, FtBlob:
+		// pointer of the memo chunk
+		return filemanager.Int64Length, nil
+	}
+
+	return 0, fmt.Errorf("field type not implemented in calculateRecordSize %d", f.Type)
+}
+
+// indexOptions returns the options of the indexes of the field
+func (f Field) indexOptions() btree.Options {
+	return btree.Options{KeyType: f.keyType(), Nullable: f.Nullable}
+}
+
+// hasNullable reports if the records are prefixed with a null bitmap
+func (d FieldDef) hasNullable() bool {
+	for _, field := range d.Fields {
+		if field.Nullable {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nullBitmapSize returns the length of the null bitmap, one bit for each field
+func (d FieldDef) nullBitmapSize() int {
+	if !d.hasNullable() {
+		return 0
+	}
+
+	return (len(d.Fields) + 7) / 8
+}
+
+func setNull(record []byte, fieldInd int) {
+	record[fieldInd/8] |= 1 << (fieldInd % 8)
+}
+
+func isNull(record []byte, fieldInd int) bool {
+	return record[fieldInd/8]&(1<<(fieldInd%8)) != 0
+}
+
+// hasMemo reports if the table needs a memo file
+func (d FieldDef) hasMemo() bool {
+	for _, field := range d.Fields {
+		if field.isMemo() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// keyType returns how the index keys of the field are compared
+func (f Field) keyType() btree.KeyType {
+	switch f.Type {
+	case FtInt, FtDate, FtDateTime:
+		return btree.KeyInt
+	case FtReal:
+		return btree.KeyReal
+	}
+
+	return btree.KeyText
+}
+
+// field returns the definition of the field by it's name
+func (c *CurrentTable) field(name string) (*Field, bool) {
+	return c.fieldDef.field(name)
+}
+
+func (d *FieldDef) field(name string) (*Field, bool) {
+	for x, field := range d.Fields {
+		if field.Name == name {
+			return &d.Fields[x], true
+		}
+	}
+
+	return nil, false
 }
 
 // CursorPos returns the current cursor position
@@ -63,6 +182,32 @@ func (c *CurrentTable) CursorPos() int64 {
 	return c.recordNo
 }
 
+// Eof reports if the cursor is after the last record, e.g. after a seek not finding the key
+func (c *CurrentTable) Eof() bool {
+	return c.recordNo >= c.recordCount
+}
+
+// SetSoftSeek sets the soft seek mode (dBase SET SOFTSEEK), seek not finding the key moves to the next key in the order
+// of the index instead of the end of file
+func (c *CurrentTable) SetSoftSeek(on bool) {
+	c.softSeek = on
+}
+
+// orderedIndex returns the index if it keeps the order of the keys, the cursors get an index cursor of their own
+func (c *CurrentTable) orderedIndex(index tableIndex) (OrderedIndex, error) {
+	ordered, ok := index.ordered()
+	if !ok || !c.cursor {
+		return ordered, nil
+	}
+
+	cursors, ok := ordered.(CursorIndex)
+	if !ok {
+		return nil, fmt.Errorf("index %s cannot be used by cursors, it has no cursors of it's own", index.Name)
+	}
+
+	return cursors.NewCursor(), nil
+}
+
 // CursorCount Return the number of cursors (rows)
 func (c *CurrentTable) CursorCount() int64 {
 	if c.fileHandlers.rpt == nil {
@@ -78,8 +223,12 @@ func (c *CurrentTable) CursorCount() int64 {
 	return stat.Size() / (filemanager.Int64Length + 1)
 }
 
-// Close closes the file handles in the table
+// Close closes the file handles in the table, closing a cursor only leaves it
 func (c *CurrentTable) Close() error {
+	if c.cursor {
+		return nil
+	}
+
 	errors := make([]string, 0)
 	err := c.fileHandlers.dat.Close()
 	if err != nil {
@@ -91,16 +240,18 @@ func (c *CurrentTable) Close() error {
 		errors = append(errors, err.Error())
 	}
 
+	if c.fileHandlers.dbt != nil {
+		err = c.fileHandlers.dbt.Close()
+		if err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+
 	// close indexes
-	for _, field := range c.fieldDef.Fields {
-		if field.Indexes != nil {
-			for _, index := range field.Indexes {
-				index := *index.index
-				err := index.Close()
-				if err != nil {
-					errors = append(errors, err.Error())
-				}
-			}
+	for _, index := range c.indexes {
+		err := index.index.Close()
+		if err != nil {
+			errors = append(errors, err.Error())
 		}
 	}
 
@@ -112,10 +263,10 @@ func (c *CurrentTable) Close() error {
 }
 
 func newTableOpener(tableName string) (*CurrentTable, error) {
-	o := &CurrentTable{
+	o := &CurrentTable{sharedTable: &sharedTable{
 		tableName: tableName,
 		filer:     filemanager.New(),
-	}
+	}}
 	table, err := o.init()
 	if err != nil {
 		return nil, err
@@ -156,6 +307,13 @@ func (c *CurrentTable) init() (*CurrentTable, error) {
 		return nil, err
 	}
 
+	if c.fieldDef.hasMemo() {
+		err = c.openMemoFile()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	rs, err := c.calculateRecordSize()
 	if err != nil {
 		return nil, err
@@ -177,21 +335,18 @@ func (c *CurrentTable) Struct() *FieldDef {
 }
 
 func (c *CurrentTable) openIndexes() error {
-	for x, field := range c.fieldDef.Fields {
-		intIndex := false
-		if field.Type == FtInt {
-			intIndex = true
-		}
-		if field.Indexes != nil {
-			for y, index := range field.Indexes {
-				bTree, err := btree.New(index.Name, field.Length, intIndex)
-				if err != nil {
-					return err
-				}
-				c.fieldDef.Fields[x].Indexes[y].index = &bTree
-			}
+	indexes, err := tableIndexes(&c.fieldDef)
+	if err != nil {
+		return err
+	}
+
+	for x := range indexes {
+		err := indexes[x].open()
+		if err != nil {
+			return err
 		}
 	}
+	c.indexes = indexes
 
 	return nil
 }
@@ -218,6 +373,17 @@ func (c *CurrentTable) openDatFile() error {
 	return nil
 }
 
+func (c *CurrentTable) openMemoFile() error {
+	filePath := c.filer.GetFullFilePath(c.tableName + memoFileExt)
+	file, err := c.openRw(filePath)
+	if err != nil {
+		return err
+	}
+
+	c.fileHandlers.dbt = file
+	return nil
+}
+
 func (*CurrentTable) openRw(filePath string) (*os.File, error) {
 	file, err := os.OpenFile(filePath, os.O_RDWR, 0644)
 	if err != nil {
@@ -228,19 +394,14 @@ func (*CurrentTable) openRw(filePath string) (*os.File, error) {
 }
 
 func (c *CurrentTable) calculateRecordSize() (int, error) {
-	size := 0
+	size := c.fieldDef.nullBitmapSize()
 
 	for _, field := range c.fieldDef.Fields {
-		switch field.Type {
-		case FtText:
-			size += field.Length
-		case FtBool:
-			size++
-		case FtInt:
-			size += filemanager.Int64Length
-		default:
-			return 0, fmt.Errorf("field type not implemented in calculateRecordSize %d", field.Type)
+		fieldSize, err := field.size()
+		if err != nil {
+			return 0, err
 		}
+		size += fieldSize
 	}
 
 	return size, nil
@@ -0,0 +1,74 @@
+package localdb
+
+import (
+	"errors"
+	"fmt"
+)
+
+// IndexTypeUnique is the type of the unique indexes, same as setting IndexDef.Unique
+const IndexTypeUnique = "unique"
+
+// ErrDuplicateKey is the error of a key already in a unique index, returned wrapped in a DuplicateKeyError
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// DuplicateKeyError names the unique index and the value violating it
+type DuplicateKeyError struct {
+	Index string
+	Value interface{}
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return fmt.Sprintf("%s '%v' in unique index %s", ErrDuplicateKey.Error(), e.Value, e.Index)
+}
+
+func (e *DuplicateKeyError) Unwrap() error {
+	return ErrDuplicateKey
+}
+
+func (d IndexDef) isUnique() bool {
+	return d.Unique || d.Type == IndexTypeUnique
+}
+
+// checkUnique verifies that no unique index contains the keys of the data yet, nulls are never duplicates and
+// filtered indexes only check the records matching the filter
+func (i *ins) checkUnique(data map[string]interface{}) error {
+	for _, index := range i.CurrentTable.indexes {
+		if !index.isUnique() || index.isNull(data) {
+			continue
+		}
+
+		included, err := index.includes(i, data)
+		if err != nil {
+			return err
+		}
+
+		if !included {
+			continue
+		}
+
+		key, err := i.key(index, data)
+		if err != nil {
+			return err
+		}
+
+		err = checkUniqueKey(index, key, index.value(data))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkUniqueKey(index tableIndex, key []byte, value interface{}) error {
+	found, err := index.index.Exists(key)
+	if err != nil {
+		return err
+	}
+
+	if found {
+		return &DuplicateKeyError{Index: index.Name, Value: value}
+	}
+
+	return nil
+}
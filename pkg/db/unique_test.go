@@ -0,0 +1,134 @@
+package localdb
+
+import (
+	"errors"
+	filemanager "godb/pkg/file"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type uniqueTestSuite struct {
+	suite.Suite
+	db Manager
+	ct *CurrentTable
+}
+
+func TestUniqueRunner(t *testing.T) {
+	suite.Run(t, new(uniqueTestSuite))
+}
+
+func (t *uniqueTestSuite) SetupTest() {
+	err := os.RemoveAll(filemanager.DefaultFolder)
+	if err != nil {
+		panic("Cannot run test, the folder cannot be removed " + err.Error())
+	}
+
+	t.db = New()
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "id", Type: FtInt, Indexes: []IndexDef{{Name: "unique_id", Unique: true}}},
+			{Name: "email", Type: FtText, Length: 20, Nullable: true, Indexes: []IndexDef{{Name: "unique_email", Type: IndexTypeUnique}}},
+			{Name: "name", Type: FtText, Length: 10, Indexes: []IndexDef{{Name: "unique_name"}}},
+		},
+	}
+	tableName := "unique_tests"
+	err = t.db.Create(tableName, tableStruct)
+	if err != nil {
+		panic("Cannot run test, Could not create database " + err.Error())
+	}
+
+	ct, err := t.db.Open(tableName)
+	if err != nil {
+		panic("Cannot open table " + err.Error())
+	}
+
+	t.ct = ct
+
+	rows := []map[string]interface{}{
+		{"id": int64(1), "email": "john@example.com", "name": "john"},
+		{"id": int64(2), "email": nil, "name": "john"},
+		{"id": int64(3), "email": nil, "name": "jane"},
+	}
+	for _, row := range rows {
+		_, err = t.db.Insert(t.ct, row)
+		if err != nil {
+			panic("Cannot insert test data " + err.Error())
+		}
+	}
+}
+
+func (t *uniqueTestSuite) TearDownTest() {
+	t.ct.Close()
+	t.db = nil
+}
+
+func (t *uniqueTestSuite) TestInsertRejectsDuplicates() {
+	size := t.ct.recordSize * 3
+
+	_, err := t.db.Insert(t.ct, map[string]interface{}{"id": int64(2), "email": "new@example.com", "name": "bob"})
+	t.ErrorIs(err, ErrDuplicateKey)
+	var dupErr *DuplicateKeyError
+	t.True(errors.As(err, &dupErr))
+	t.Equal("unique_id", dupErr.Index)
+	t.Equal(int64(2), dupErr.Value)
+	t.Equal("duplicate key '2' in unique index unique_id", err.Error())
+
+	_, err = t.db.Insert(t.ct, map[string]interface{}{"id": int64(4), "email": "john@example.com", "name": "bob"})
+	t.True(errors.As(err, &dupErr))
+	t.Equal("unique_email", dupErr.Index)
+
+	// Nothing was written
+	stat, err := t.ct.fileHandlers.dat.Stat()
+	t.Nil(err)
+	t.Equal(int64(size), stat.Size())
+	rc, err := t.db.RecCount(t.ct)
+	t.Nil(err)
+	t.Equal(int64(3), rc)
+
+	err = t.db.Use(t.ct, "unique_email")
+	t.Nil(err)
+	_, err = t.db.Locate(t.ct, "email", "new@example.com")
+	t.ErrorIs(err, errNotFound)
+
+	// Nulls and non unique indexes accept duplicates
+	_, err = t.db.Insert(t.ct, map[string]interface{}{"id": int64(4), "email": nil, "name": "john"})
+	t.Nil(err)
+}
+
+func (t *uniqueTestSuite) TestUpdateRejectsDuplicates() {
+	err := t.db.Update(t.ct, 2, map[string]interface{}{"id": int64(1)})
+	t.ErrorIs(err, ErrDuplicateKey)
+
+	err = t.db.Update(t.ct, 2, map[string]interface{}{"email": "john@example.com"})
+	t.ErrorIs(err, ErrDuplicateKey)
+
+	res, _, _, err := t.db.Fetch(t.ct, 2)
+	t.Nil(err)
+	t.Equal(int64(3), res["id"])
+	t.Nil(res["email"])
+
+	// Keeping the own key is not a duplicate
+	err = t.db.Update(t.ct, 0, map[string]interface{}{"id": int64(1), "name": "johnny"})
+	t.Nil(err)
+
+	err = t.db.Update(t.ct, 2, map[string]interface{}{"id": int64(10), "email": "jane@example.com"})
+	t.Nil(err)
+}
+
+func (t *uniqueTestSuite) TestRecallRejectsDuplicates() {
+	err := t.db.Delete(t.ct, 0)
+	t.Nil(err)
+
+	// The deleted key is free again
+	_, err = t.db.Insert(t.ct, map[string]interface{}{"id": int64(1), "email": "other@example.com", "name": "bob"})
+	t.Nil(err)
+
+	err = t.db.Recall(t.ct, 0)
+	t.ErrorIs(err, ErrDuplicateKey)
+
+	isDeleted, err := t.db.IsDeleted(t.ct, 0)
+	t.Nil(err)
+	t.True(isDeleted)
+}
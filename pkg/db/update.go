@@ -0,0 +1,157 @@
+package localdb
+
+import (
+	"bytes"
+	"fmt"
+	filemanager "godb/pkg/file"
+)
+
+func newUpdater() updater {
+	filer := filemanager.New()
+	return &upd{
+		filer:    filer,
+		inserter: &ins{filer: filer},
+		fetcher:  &fetch{filer: filer},
+	}
+}
+
+type updater interface {
+	Update(c *CurrentTable, recNo int64, data map[string]interface{}) error
+}
+
+type upd struct {
+	filer    filemanager.Filer
+	inserter *ins
+	fetcher  *fetch
+}
+
+// indexChange holds the keys to replace in the index, nil key is not in the index (skipped null or filtered out)
+type indexChange struct {
+	index  Index
+	oldKey []byte
+	newKey []byte
+}
+
+// Update rewrites the record in place, fields missing from data keep their current value
+func (u *upd) Update(c *CurrentTable, recNo int64, data map[string]interface{}) error {
+	u.inserter.CurrentTable = c
+
+	oldRecord, datFilePointer, eof, isDeleted, err := u.fetcher.readRecord(c, recNo)
+	if err != nil {
+		return err
+	}
+
+	if eof {
+		return fmt.Errorf("record %d does not exists, cannot update it", recNo)
+	}
+
+	if isDeleted {
+		return fmt.Errorf("record %d is deleted, cannot update it", recNo)
+	}
+
+	err = validateRequired(c.fieldDef.Fields, data, true)
+	if err != nil {
+		return err
+	}
+
+	oldData, err := u.fetcher.mapBufferToData(c, oldRecord)
+	if err != nil {
+		return err
+	}
+
+	newData := make(map[string]interface{}, len(oldData))
+	for _, field := range c.fieldDef.Fields {
+		if val, ok := data[field.Name]; ok {
+			newData[field.Name] = val
+			continue
+		}
+
+		// The memo chunk of the record is kept as it is
+		if field.isMemo() {
+			continue
+		}
+		newData[field.Name] = oldData[field.Name]
+	}
+
+	changes, err := u.indexChanges(c, oldData, newData)
+	if err != nil {
+		return err
+	}
+
+	record, err := u.inserter.recordAsBytes(newData, oldRecord)
+	if err != nil {
+		return err
+	}
+
+	err = u.filer.WriteBytes(c.fileHandlers.dat, datFilePointer, record)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		if change.oldKey != nil {
+			err = change.index.Delete(change.oldKey, recNo)
+			if err != nil {
+				return err
+			}
+		}
+
+		if change.newKey != nil {
+			err = change.index.Insert(change.newKey, recNo)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// indexChanges collects the indexes where the key has to be replaced
+func (u *upd) indexChanges(c *CurrentTable, oldData, newData map[string]interface{}) ([]indexChange, error) {
+	changes := make([]indexChange, 0)
+	for _, index := range c.indexes {
+		oldKey, err := u.inserter.key(index, oldData)
+		if err != nil {
+			return nil, err
+		}
+
+		newKey, err := u.inserter.key(index, newData)
+		if err != nil {
+			return nil, err
+		}
+
+		oldIncluded, err := index.includes(u.inserter, oldData)
+		if err != nil {
+			return nil, err
+		}
+
+		newIncluded, err := index.includes(u.inserter, newData)
+		if err != nil {
+			return nil, err
+		}
+
+		if (!oldIncluded && !newIncluded) || (oldIncluded == newIncluded && bytes.Equal(oldKey, newKey)) {
+			continue
+		}
+
+		change := indexChange{index: index.index, oldKey: oldKey, newKey: newKey}
+		if !oldIncluded {
+			change.oldKey = nil
+		}
+
+		if !newIncluded {
+			change.newKey = nil
+		}
+
+		if index.isUnique() && newIncluded && !index.isNull(newData) {
+			err = checkUniqueKey(index, newKey, index.value(newData))
+			if err != nil {
+				return nil, err
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,147 @@
+package localdb
+
+import (
+	filemanager "godb/pkg/file"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type updateTestSuite struct {
+	suite.Suite
+	db Manager
+	ct *CurrentTable
+}
+
+func TestUpdateRunner(t *testing.T) {
+	suite.Run(t, new(updateTestSuite))
+}
+
+func (t *updateTestSuite) SetupTest() {
+	err := os.RemoveAll(filemanager.DefaultFolder)
+	if err != nil {
+		panic("Cannot run test, the folder cannot be removed " + err.Error())
+	}
+
+	t.db = New()
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "field_1", Type: FtText, Length: 15, Indexes: []IndexDef{{Name: "upd_field_1"}}},
+			{Name: "field_2", Type: FtBool},
+			{Name: "field_3", Type: FtInt, Indexes: []IndexDef{{Name: "upd_field_3"}}},
+		},
+	}
+	tableName := "update_tests"
+	err = t.db.Create(tableName, tableStruct)
+	if err != nil {
+		panic("Cannot run test, Could not create database " + err.Error())
+	}
+
+	ct, err := t.db.Open(tableName)
+	if err != nil {
+		panic("Cannot open table " + err.Error())
+	}
+
+	t.ct = ct
+
+	for i := 0; i < 10; i++ {
+		_, err = t.db.Insert(t.ct, map[string]interface{}{
+			"field_1": "data " + strconv.Itoa(i),
+			"field_2": true,
+			"field_3": int64(i),
+		})
+		if err != nil {
+			panic("Cannot insert test data " + err.Error())
+		}
+	}
+}
+
+func (t *updateTestSuite) TearDownTest() {
+	t.ct.Close()
+	t.db = nil
+}
+
+func (t *updateTestSuite) TestUpdateKeepsRecordNumberAndMissingFields() {
+	err := t.db.Update(t.ct, 3, map[string]interface{}{"field_1": "changed"})
+	t.Nil(err)
+
+	rc, err := t.db.RecCount(t.ct)
+	t.Nil(err)
+	t.Equal(int64(10), rc)
+
+	res, _, _, err := t.db.Fetch(t.ct, 3)
+	t.Nil(err)
+	t.Equal("changed", res["field_1"])
+	t.Equal(true, res["field_2"])
+	t.Equal(int64(3), res["field_3"])
+
+	res, _, _, err = t.db.Fetch(t.ct, 4)
+	t.Nil(err)
+	t.Equal("data 4", res["field_1"])
+}
+
+func (t *updateTestSuite) TestUpdateMaintainsIndexes() {
+	err := t.db.Update(t.ct, 3, map[string]interface{}{"field_1": "changed"})
+	t.Nil(err)
+
+	err = t.db.Use(t.ct, "upd_field_1")
+	t.Nil(err)
+
+	_, err = t.db.Locate(t.ct, "field_1", "data 3")
+	t.ErrorIs(err, errNotFound)
+
+	res, err := t.db.Locate(t.ct, "field_1", "changed")
+	t.Nil(err)
+	t.Equal(int64(3), res["_recNo"])
+
+	// changed sorts before all "data n" keys
+	err = t.db.First(t.ct)
+	t.Nil(err)
+	t.Equal(int64(3), t.ct.CursorPos())
+
+	err = t.db.Last(t.ct)
+	t.Nil(err)
+	t.Equal(int64(9), t.ct.CursorPos())
+
+	count := 1
+	for {
+		eof, err := t.db.Prev(t.ct)
+		t.Nil(err)
+		if eof {
+			break
+		}
+		count++
+	}
+	t.Equal(10, count)
+}
+
+func (t *updateTestSuite) TestUpdateBackToOriginalValue() {
+	err := t.db.Update(t.ct, 3, map[string]interface{}{"field_1": "changed", "field_3": int64(100)})
+	t.Nil(err)
+
+	err = t.db.Update(t.ct, 3, map[string]interface{}{"field_1": "data 3", "field_3": int64(3)})
+	t.Nil(err)
+
+	err = t.db.Use(t.ct, "upd_field_1")
+	t.Nil(err)
+
+	res, err := t.db.Locate(t.ct, "field_1", "data 3")
+	t.Nil(err)
+	t.Equal(int64(3), res["_recNo"])
+
+	_, err = t.db.Locate(t.ct, "field_1", "changed")
+	t.ErrorIs(err, errNotFound)
+}
+
+func (t *updateTestSuite) TestUpdateDeletedRecordFails() {
+	err := t.db.Delete(t.ct, 2)
+	t.Nil(err)
+
+	err = t.db.Update(t.ct, 2, map[string]interface{}{"field_1": "changed"})
+	t.Error(err)
+
+	err = t.db.Update(t.ct, 20, map[string]interface{}{"field_1": "changed"})
+	t.Error(err)
+}
@@ -0,0 +1,137 @@
+package localdb
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Default value generators, "now" works on date and date time fields, "uuid" on text and memo fields.
+// On other field types they are literal values
+const (
+	DefaultNow  = "now"
+	DefaultUUID = "uuid"
+)
+
+// Text overflow policies
+const (
+	OverflowError    = "error"
+	OverflowTruncate = "truncate"
+	OverflowWarn     = "warn"
+)
+
+// ValidationError lists every field of the record failing the validation
+type ValidationError struct {
+	Fields []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("required fields are missing: %s", strings.Join(e.Fields, ", "))
+}
+
+// withDefaults returns a copy of the data with the default values of the missing fields
+func (i *ins) withDefaults(data map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(data))
+	for name, value := range data {
+		result[name] = value
+	}
+
+	for _, field := range i.CurrentTable.fieldDef.Fields {
+		if _, ok := data[field.Name]; ok || field.Default == nil {
+			continue
+		}
+
+		value, err := field.defaultValue()
+		if err != nil {
+			return nil, err
+		}
+		result[field.Name] = value
+	}
+
+	return result, nil
+}
+
+func (f Field) defaultValue() (interface{}, error) {
+	switch f.Default {
+	case DefaultNow:
+		if f.Type == FtDate || f.Type == FtDateTime {
+			return time.Now(), nil
+		}
+	case DefaultUUID:
+		if f.Type == FtText || f.Type == FtMemo {
+			return newUUID()
+		}
+	}
+
+	return f.Default, nil
+}
+
+// validateRequired checks that the required fields have a value, missing fields are only accepted in partial data (update)
+func validateRequired(fields []Field, data map[string]interface{}, partial bool) error {
+	missing := make([]string, 0)
+	for _, field := range fields {
+		if !field.Required {
+			continue
+		}
+
+		value, ok := data[field.Name]
+		if (!ok && !partial) || (ok && value == nil) {
+			missing = append(missing, field.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return &ValidationError{Fields: missing}
+	}
+
+	return nil
+}
+
+// checkOverflow applies the overflow policy of the field when the text is longer than the field length
+func checkOverflow(field Field, value interface{}) error {
+	val, ok := value.(string)
+	if !ok || len(val) <= field.Length {
+		return nil
+	}
+
+	switch field.Overflow {
+	case OverflowTruncate:
+	case OverflowWarn:
+		log.Printf("field %s value of %d bytes is truncated to the field length %d", field.Name, len(val), field.Length)
+	default:
+		return fmt.Errorf("field %s value of %d bytes is longer than the field length %d", field.Name, len(val), field.Length)
+	}
+
+	return nil
+}
+
+// truncateText cuts the text to the length without splitting a multibyte character
+func truncateText(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+
+	cut := length
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+
+	return s[:cut]
+}
+
+// newUUID generates a random (version 4) UUID
+func newUUID() (string, error) {
+	buf := make([]byte, 16)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+
+	buf[6] = buf[6]&0x0f | 0x40
+	buf[8] = buf[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", buf[0:4], buf[4:6], buf[6:8], buf[8:10], buf[10:]), nil
+}
@@ -0,0 +1,157 @@
+package localdb
+
+import (
+	"bytes"
+	"errors"
+	filemanager "godb/pkg/file"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type validateTestSuite struct {
+	suite.Suite
+	db Manager
+	ct *CurrentTable
+}
+
+func TestValidateRunner(t *testing.T) {
+	suite.Run(t, new(validateTestSuite))
+}
+
+func (t *validateTestSuite) SetupTest() {
+	err := os.RemoveAll(filemanager.DefaultFolder)
+	if err != nil {
+		panic("Cannot run test, the folder cannot be removed " + err.Error())
+	}
+
+	t.db = New()
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "code", Type: FtText, Length: 36, Required: true, Default: DefaultUUID},
+			{Name: "name", Type: FtText, Length: 10, Required: true},
+			{Name: "city", Type: FtText, Length: 10, Required: true},
+			{Name: "created", Type: FtDateTime, Default: DefaultNow},
+			{Name: "qty", Type: FtInt, Default: 1},
+			{Name: "status", Type: FtText, Length: 10, Default: DefaultNow},
+			{Name: "tag", Type: FtText, Length: 5, Default: "", Overflow: OverflowTruncate, Indexes: []IndexDef{{Name: "validate_tag"}}},
+			{Name: "note", Type: FtText, Length: 5, Default: "", Overflow: OverflowWarn},
+		},
+	}
+	tableName := "validate_tests"
+	err = t.db.Create(tableName, tableStruct)
+	if err != nil {
+		panic("Cannot run test, Could not create database " + err.Error())
+	}
+
+	// Reopened definition, the defaults are read back from the json
+	ct, err := t.db.Open(tableName)
+	if err != nil {
+		panic("Cannot open table " + err.Error())
+	}
+
+	t.ct = ct
+}
+
+func (t *validateTestSuite) TearDownTest() {
+	t.ct.Close()
+	t.db = nil
+}
+
+func (t *validateTestSuite) TestRequiredFields() {
+	_, err := t.db.Insert(t.ct, map[string]interface{}{"city": nil})
+	t.Error(err)
+
+	var validationErr *ValidationError
+	t.True(errors.As(err, &validationErr))
+	t.Equal([]string{"name", "city"}, validationErr.Fields)
+	t.Equal("required fields are missing: name, city", err.Error())
+
+	rc, err := t.db.RecCount(t.ct)
+	t.Nil(err)
+	t.Equal(int64(0), rc)
+
+	_, err = t.db.Insert(t.ct, map[string]interface{}{"name": "john", "city": "london"})
+	t.Nil(err)
+
+	// Update only validates the fields it changes
+	err = t.db.Update(t.ct, 0, map[string]interface{}{"city": "paris"})
+	t.Nil(err)
+
+	err = t.db.Update(t.ct, 0, map[string]interface{}{"name": nil})
+	t.True(errors.As(err, &validationErr))
+	t.Equal([]string{"name"}, validationErr.Fields)
+}
+
+func (t *validateTestSuite) TestDefaultValues() {
+	before := time.Now().Add(-time.Second)
+	data := map[string]interface{}{"name": "john", "city": "london"}
+	_, err := t.db.Insert(t.ct, data)
+	t.Nil(err)
+	t.Len(data, 2)
+
+	_, err = t.db.Insert(t.ct, map[string]interface{}{"name": "jane", "city": "rome", "code": "fixed", "qty": 5})
+	t.Nil(err)
+
+	res, _, _, err := t.db.Fetch(t.ct, 0)
+	t.Nil(err)
+	t.Regexp("^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$", res["code"])
+	t.WithinRange(res["created"].(time.Time), before, time.Now())
+	t.Equal(int64(1), res["qty"])
+	// Generators only apply to the matching field types
+	t.Equal(DefaultNow, res["status"])
+
+	res, _, _, err = t.db.Fetch(t.ct, 1)
+	t.Nil(err)
+	t.Equal("fixed", res["code"])
+	t.Equal(int64(5), res["qty"])
+}
+
+func (t *validateTestSuite) TestTextOverflow() {
+	_, err := t.db.Insert(t.ct, map[string]interface{}{"name": "a name longer than ten", "city": "london"})
+	t.EqualError(err, "field name value of 22 bytes is longer than the field length 10")
+
+	rc, err := t.db.RecCount(t.ct)
+	t.Nil(err)
+	t.Equal(int64(0), rc)
+
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	// "é" is 2 bytes, the cut must not split it
+	_, err = t.db.Insert(t.ct, map[string]interface{}{"name": "john", "city": "london", "tag": "abcdé", "note": "abcdéf"})
+	t.Nil(err)
+	t.Contains(logged.String(), "field note value of 7 bytes is truncated to the field length 5")
+
+	res, _, _, err := t.db.Fetch(t.ct, 0)
+	t.Nil(err)
+	t.Equal("abcd", res["tag"])
+	t.Equal("abcd", res["note"])
+
+	err = t.db.Use(t.ct, "validate_tag")
+	t.Nil(err)
+	res, err = t.db.Locate(t.ct, "tag", "abcd")
+	t.Nil(err)
+	t.Equal(int64(0), res["_recNo"])
+
+	// Update uses the same policy
+	err = t.db.Update(t.ct, 0, map[string]interface{}{"city": "a city longer than ten"})
+	t.EqualError(err, "field city value of 22 bytes is longer than the field length 10")
+
+	err = t.db.Update(t.ct, 0, map[string]interface{}{"tag": "ééé"})
+	t.Nil(err)
+	res, _, _, err = t.db.Fetch(t.ct, 0)
+	t.Nil(err)
+	t.Equal("éé", res["tag"])
+	t.Equal("london", res["city"])
+
+	tableStruct := &FieldDef{
+		Fields: []Field{{Name: "name", Type: FtText, Length: 5, Overflow: "ignore"}},
+	}
+	err = t.db.Create("overflow_tests", tableStruct)
+	t.Error(err)
+}
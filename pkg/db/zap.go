@@ -0,0 +1,86 @@
+package localdb
+
+import filemanager "godb/pkg/file"
+
+func newZapper() zapper {
+	return &zp{filer: filemanager.New()}
+}
+
+type zapper interface {
+	Zap(c *CurrentTable) error
+}
+
+type zp struct {
+	filer filemanager.Filer
+}
+
+// Zap removes all records from the table, the definition and the (now empty) indexes are kept
+func (z *zp) Zap(c *CurrentTable) error {
+	err := c.fileHandlers.dat.Close()
+	if err != nil {
+		return err
+	}
+
+	err = c.fileHandlers.rpt.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, fileName := range []string{c.tableName + dataFileExt, c.tableName + recordPointerFileExt} {
+		err := z.filer.CreateBlankFileOverwriteIfExist(fileName)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.openDatFile()
+	if err != nil {
+		return err
+	}
+
+	err = c.openPointerFile()
+	if err != nil {
+		return err
+	}
+
+	err = z.zapMemoFile(c)
+	if err != nil {
+		return err
+	}
+
+	for _, index := range c.indexes {
+		err := index.index.Reset()
+		if err != nil {
+			return err
+		}
+	}
+
+	c.recordCount = 0
+	c.recordNo = 0
+
+	return nil
+}
+
+func (z *zp) zapMemoFile(c *CurrentTable) error {
+	if c.fileHandlers.dbt == nil {
+		return nil
+	}
+
+	err := c.fileHandlers.dbt.Close()
+	if err != nil {
+		return err
+	}
+
+	fileName := c.tableName + memoFileExt
+	err = z.filer.CreateBlankFileOverwriteIfExist(fileName)
+	if err != nil {
+		return err
+	}
+
+	err = writeMemoHeader(z.filer, fileName)
+	if err != nil {
+		return err
+	}
+
+	return c.openMemoFile()
+}
@@ -0,0 +1,109 @@
+package localdb
+
+import (
+	filemanager "godb/pkg/file"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type zapTestSuite struct {
+	suite.Suite
+	db Manager
+	ct *CurrentTable
+}
+
+func TestZapRunner(t *testing.T) {
+	suite.Run(t, new(zapTestSuite))
+}
+
+func (t *zapTestSuite) SetupTest() {
+	err := os.RemoveAll(filemanager.DefaultFolder)
+	if err != nil {
+		panic("Cannot run test, the folder cannot be removed " + err.Error())
+	}
+
+	t.db = New()
+	tableStruct := &FieldDef{
+		Fields: []Field{
+			{Name: "field_1", Type: FtText, Length: 15, Indexes: []IndexDef{{Name: "zap_field_1"}}},
+			{Name: "field_2", Type: FtInt, Indexes: []IndexDef{{Name: "zap_field_2"}}},
+		},
+	}
+	tableName := "zap_tests"
+	err = t.db.Create(tableName, tableStruct)
+	if err != nil {
+		panic("Cannot run test, Could not create database " + err.Error())
+	}
+
+	ct, err := t.db.Open(tableName)
+	if err != nil {
+		panic("Cannot open table " + err.Error())
+	}
+
+	t.ct = ct
+
+	for i := 0; i < 100; i++ {
+		_, err = t.db.Insert(t.ct, map[string]interface{}{
+			"field_1": "data " + strconv.Itoa(i),
+			"field_2": int64(i),
+		})
+		if err != nil {
+			panic("Cannot insert test data " + err.Error())
+		}
+	}
+}
+
+func (t *zapTestSuite) TearDownTest() {
+	t.ct.Close()
+	t.db = nil
+}
+
+func (t *zapTestSuite) TestZapEmptiesTableAndIndexes() {
+	err := t.db.Use(t.ct, "zap_field_1")
+	t.Nil(err)
+
+	err = t.db.Zap(t.ct)
+	t.Nil(err)
+
+	rc, err := t.db.RecCount(t.ct)
+	t.Nil(err)
+	t.Equal(int64(0), rc)
+	t.Equal(int64(0), t.ct.CursorPos())
+
+	_, eof, _, err := t.db.Fetch(t.ct, 0)
+	t.Nil(err)
+	t.True(eof)
+
+	_, err = t.db.Locate(t.ct, "field_1", "data 5")
+	t.ErrorIs(err, errNotFound)
+
+	_, err = t.db.Insert(t.ct, map[string]interface{}{"field_1": "new data", "field_2": int64(5)})
+	t.Nil(err)
+
+	res, err := t.db.Locate(t.ct, "field_1", "new data")
+	t.Nil(err)
+	t.Equal(int64(0), res["_recNo"])
+
+	err = t.db.Use(t.ct, "zap_field_2")
+	t.Nil(err)
+
+	err = t.db.First(t.ct)
+	t.Nil(err)
+	eof, err = t.db.Next(t.ct)
+	t.Nil(err)
+	t.True(eof)
+
+	// Definition is kept, the table can be reopened
+	err = t.ct.Close()
+	t.Nil(err)
+
+	t.ct, err = t.db.Open("zap_tests")
+	t.Nil(err)
+
+	rc, err = t.db.RecCount(t.ct)
+	t.Nil(err)
+	t.Equal(int64(1), rc)
+}
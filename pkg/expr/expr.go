@@ -0,0 +1,552 @@
+// Package expr parses and evaluates dBase like expressions over the fields of a record, e.g. UPPER(name)+city or
+// active .AND. qty > 0
+package expr
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Resolver returns the value of a field of the record
+type Resolver func(name string) (interface{}, error)
+
+// Sizer returns the maximal text width of a field
+type Sizer func(name string) (int, error)
+
+// Expression is a parsed expression
+type Expression struct {
+	src    string
+	root   node
+	fields []string
+}
+
+type node interface {
+	eval(fields Resolver) (interface{}, error)
+	width(sizes Sizer) (int, error)
+}
+
+// Parse parses the source of the expression
+func Parse(src string) (*Expression, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().kind != tkEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.peek().text, p.peek().pos)
+	}
+
+	return &Expression{src: src, root: root, fields: p.fields}, nil
+}
+
+// String returns the source of the expression
+func (e *Expression) String() string {
+	return e.src
+}
+
+// Fields lists the fields used by the expression in order of appearance
+func (e *Expression) Fields() []string {
+	return e.fields
+}
+
+// Eval evaluates the expression, the fields are read by the resolver
+func (e *Expression) Eval(fields Resolver) (interface{}, error) {
+	return e.root.eval(fields)
+}
+
+// Width returns the maximal width of the text result of the expression
+func (e *Expression) Width(sizes Sizer) (int, error) {
+	return e.root.width(sizes)
+}
+
+// Match evaluates the expression as a condition, a missing (nil) value does not match, other values than logical fail
+func (e *Expression) Match(fields Resolver) (bool, error) {
+	result, err := e.root.eval(fields)
+	if err != nil || result == nil {
+		return false, err
+	}
+
+	match, ok := result.(bool)
+	if !ok {
+		return false, fmt.Errorf("condition %s must give a logical value, got %T", e.src, result)
+	}
+
+	return match, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	fields []string
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tkEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	t := p.next()
+	if t.kind != kind {
+		return fmt.Errorf("expected %s at position %d", text, t.pos)
+	}
+
+	return nil
+}
+
+// parseExpr parses the conditions joined by OR, AND binds stronger, NOT even more, then the comparison and +
+func (p *parser) parseExpr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tkOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logical{or: true, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tkAnd {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logical{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.peek().kind != tkNot {
+		return p.parseCompare()
+	}
+	p.next()
+
+	n, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	return &not{value: n}, nil
+}
+
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().kind != tkCompare {
+		return left, nil
+	}
+	op := p.next().text
+
+	right, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+
+	return &compare{op: op, left: left, right: right}, nil
+}
+
+// parseSum parses the terms joined by +
+func (p *parser) parseSum() (node, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tkPlus {
+		p.next()
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &plus{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseTerm() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tkString:
+		return &literal{value: t.text}, nil
+	case tkNumber:
+		return parseNumber(t)
+	case tkBool:
+		return &literal{value: t.text == "T" || t.text == "TRUE"}, nil
+	case tkLParen:
+		n, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		return n, p.expect(tkRParen, ")")
+	case tkIdent:
+		if p.peek().kind == tkLParen {
+			return p.parseCall(t)
+		}
+		p.addField(t.text)
+
+		return &field{name: t.text}, nil
+	case tkEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := functions[strings.ToUpper(name.text)]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s at position %d", name.text, name.pos)
+	}
+	p.next()
+
+	args := make([]node, 0)
+	if p.peek().kind == tkRParen {
+		p.next()
+	} else {
+		for {
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+
+			if p.peek().kind != tkComma {
+				break
+			}
+			p.next()
+		}
+
+		if err := p.expect(tkRParen, ")"); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(args) < fn.minArgs || len(args) > fn.maxArgs {
+		return nil, fmt.Errorf("function %s expects %d to %d arguments, got %d", strings.ToUpper(name.text), fn.minArgs, fn.maxArgs, len(args))
+	}
+
+	return &call{name: strings.ToUpper(name.text), fn: fn, args: args}, nil
+}
+
+func (p *parser) addField(name string) {
+	for _, f := range p.fields {
+		if f == name {
+			return
+		}
+	}
+	p.fields = append(p.fields, name)
+}
+
+func parseNumber(t token) (node, error) {
+	if !strings.Contains(t.text, ".") {
+		num, err := strconv.ParseInt(t.text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s at position %d", t.text, t.pos)
+		}
+
+		return &literal{value: num}, nil
+	}
+
+	num, err := strconv.ParseFloat(t.text, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %s at position %d", t.text, t.pos)
+	}
+
+	return &literal{value: num}, nil
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (l *literal) eval(Resolver) (interface{}, error) {
+	return l.value, nil
+}
+
+func (l *literal) width(Sizer) (int, error) {
+	return len(fmt.Sprint(l.value)), nil
+}
+
+type field struct {
+	name string
+}
+
+func (f *field) eval(fields Resolver) (interface{}, error) {
+	return fields(f.name)
+}
+
+func (f *field) width(sizes Sizer) (int, error) {
+	return sizes(f.name)
+}
+
+// plus concatenates texts or adds numbers
+type plus struct {
+	left, right node
+}
+
+func (p *plus) eval(fields Resolver) (interface{}, error) {
+	left, err := p.left.eval(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := p.right.eval(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	if l, ok := left.(string); ok {
+		r, err := toText("+", right)
+		if err != nil {
+			return nil, err
+		}
+
+		return l + r, nil
+	}
+
+	if l, ok := left.(int64); ok {
+		if r, ok := right.(int64); ok {
+			return l + r, nil
+		}
+	}
+
+	l, err := toNumber("+", left)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := toNumber("+", right)
+	if err != nil {
+		return nil, err
+	}
+
+	return l + r, nil
+}
+
+func (p *plus) width(sizes Sizer) (int, error) {
+	left, err := p.left.width(sizes)
+	if err != nil {
+		return 0, err
+	}
+
+	right, err := p.right.width(sizes)
+	if err != nil {
+		return 0, err
+	}
+
+	return left + right, nil
+}
+
+type call struct {
+	name string
+	fn   function
+	args []node
+}
+
+func (c *call) eval(fields Resolver) (interface{}, error) {
+	args := make([]interface{}, len(c.args))
+	for x, arg := range c.args {
+		value, err := arg.eval(fields)
+		if err != nil {
+			return nil, err
+		}
+		args[x] = value
+	}
+
+	return c.fn.eval(c.name, args)
+}
+
+func (c *call) width(sizes Sizer) (int, error) {
+	return c.fn.width(c.args, sizes)
+}
+
+// logical is an AND or an OR of two conditions, the right side is only evaluated when needed
+type logical struct {
+	or          bool
+	left, right node
+}
+
+func (l *logical) eval(fields Resolver) (interface{}, error) {
+	left, err := evalBool(l.left, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	if left == l.or {
+		return left, nil
+	}
+
+	return evalBool(l.right, fields)
+}
+
+func (l *logical) width(Sizer) (int, error) {
+	return 0, nil
+}
+
+type not struct {
+	value node
+}
+
+func (n *not) eval(fields Resolver) (interface{}, error) {
+	value, err := evalBool(n.value, fields)
+
+	return !value, err
+}
+
+func (n *not) width(Sizer) (int, error) {
+	return 0, nil
+}
+
+func evalBool(n node, fields Resolver) (bool, error) {
+	value, err := n.eval(fields)
+	if err != nil {
+		return false, err
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	}
+
+	return false, fmt.Errorf("logical operator expects a logical value, got %T", value)
+}
+
+// compare compares values of the same type. Texts are compared without the trailing spaces, a missing (nil) value is
+// only equal to an other missing value and it is never less or greater
+type compare struct {
+	op          string
+	left, right node
+}
+
+func (c *compare) eval(fields Resolver) (interface{}, error) {
+	left, err := c.left.eval(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := c.right.eval(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	if left == nil || right == nil {
+		switch c.op {
+		case "=", "==":
+			return left == right, nil
+		case "<>", "!=", "#":
+			return left != right, nil
+		}
+
+		return false, nil
+	}
+
+	result, err := compareValues(left, right)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.op, err)
+	}
+
+	switch c.op {
+	case "=", "==":
+		return result == 0, nil
+	case "<>", "!=", "#":
+		return result != 0, nil
+	case "<":
+		return result < 0, nil
+	case "<=":
+		return result <= 0, nil
+	case ">":
+		return result > 0, nil
+	}
+
+	return result >= 0, nil
+}
+
+func (c *compare) width(Sizer) (int, error) {
+	return 0, nil
+}
+
+func compareValues(left, right interface{}) (int, error) {
+	switch l := left.(type) {
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return 0, fmt.Errorf("cannot compare text with %T", right)
+		}
+
+		return strings.Compare(strings.TrimRight(l, " "), strings.TrimRight(r, " ")), nil
+	case bool:
+		r, ok := right.(bool)
+		if !ok {
+			return 0, fmt.Errorf("cannot compare logical value with %T", right)
+		}
+
+		return cmp.Compare(boolRank(l), boolRank(r)), nil
+	case time.Time:
+		r, ok := right.(time.Time)
+		if !ok {
+			return 0, fmt.Errorf("cannot compare date with %T", right)
+		}
+
+		return l.Compare(r), nil
+	}
+
+	l, err := toNumber("compare", left)
+	if err != nil {
+		return 0, err
+	}
+
+	r, err := toNumber("compare", right)
+	if err != nil {
+		return 0, err
+	}
+
+	return cmp.Compare(l, r), nil
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
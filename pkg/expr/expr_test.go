@@ -0,0 +1,145 @@
+package expr
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type exprTestSuite struct {
+	suite.Suite
+	record map[string]interface{}
+}
+
+func TestExprRunner(t *testing.T) {
+	suite.Run(t, new(exprTestSuite))
+}
+
+func (t *exprTestSuite) SetupTest() {
+	t.record = map[string]interface{}{
+		"name":  "John  ",
+		"city":  "london",
+		"qty":   int64(42),
+		"price": 3.14159,
+		"born":  time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC),
+		"none":  nil,
+	}
+}
+
+func (t *exprTestSuite) resolve(name string) (interface{}, error) {
+	value, ok := t.record[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %s", name)
+	}
+
+	return value, nil
+}
+
+func (t *exprTestSuite) eval(src string) interface{} {
+	e, err := Parse(src)
+	t.Nil(err)
+
+	result, err := e.Eval(t.resolve)
+	t.Nil(err)
+
+	return result
+}
+
+func (t *exprTestSuite) TestFunctions() {
+	t.Equal("JOHN  london", t.eval("UPPER(name)+city"))
+	t.Equal("john  LONDON", t.eval("lower(name) + Upper(city)"))
+	t.Equal("Johnlondon", t.eval("TRIM(name)+city"))
+	t.Equal("ond", t.eval("SUBSTR(city, 2, 3)"))
+	t.Equal("ndon", t.eval("SUBSTR(city, 3)"))
+	t.Equal("", t.eval("SUBSTR(city, 10)"))
+	t.Equal("        42", t.eval("STR(qty)"))
+	t.Equal("  3.14", t.eval("STR(price, 6, 2)"))
+	t.Equal("*", t.eval("STR(qty, 1)"))
+	t.Equal("19900501", t.eval("DTOS(born)"))
+	t.Equal("        ", t.eval("DTOS(none)"))
+	t.Equal("x-london", t.eval("'x-' + (city)"))
+	t.Equal(int64(43), t.eval("qty + 1"))
+	t.Equal("", t.eval("UPPER(none)"))
+}
+
+func (t *exprTestSuite) match(src string) bool {
+	e, err := Parse(src)
+	if !t.Nil(err, src) {
+		return false
+	}
+
+	result, err := e.Match(t.resolve)
+	t.Nil(err, src)
+
+	return result
+}
+
+func (t *exprTestSuite) TestConditions() {
+	t.True(t.match("city = 'london'"))
+	t.True(t.match("name == 'John'"))
+	t.True(t.match("qty > 40 .AND. qty <= 42"))
+	t.True(t.match("qty < 10 .or. price >= 3"))
+	t.True(t.match("qty <> 1 AND NOT city # 'london'"))
+	t.False(t.match("!(qty != 42) AND born > born"))
+	t.True(t.match("DTOS(born) = '19900501'"))
+	t.True(t.match("UPPER(city) > 'K' .AND. .T."))
+	t.False(t.match(".F. OR false"))
+	t.True(t.match("(qty = 1 OR qty = 42) AND price > 3.1"))
+	t.True(t.match("none = none"))
+	t.False(t.match("none = 1"))
+	t.True(t.match("none <> 1"))
+	t.False(t.match("none < 1"))
+	t.False(t.match("none"))
+
+	e, err := Parse("UPPER(name)")
+	t.Nil(err)
+	_, err = e.Match(t.resolve)
+	t.EqualError(err, "condition UPPER(name) must give a logical value, got string")
+
+	e, err = Parse("qty = 'x'")
+	t.Nil(err)
+	_, err = e.Match(t.resolve)
+	t.Error(err)
+
+	e, err = Parse("city AND .T.")
+	t.Nil(err)
+	_, err = e.Match(t.resolve)
+	t.Error(err)
+}
+
+func (t *exprTestSuite) TestFieldsAndWidth() {
+	e, err := Parse("UPPER(name)+city+SUBSTR(name,1,2)+STR(qty,5)+DTOS(born)+'ab'")
+	t.Nil(err)
+	t.Equal([]string{"name", "city", "qty", "born"}, e.Fields())
+	t.Equal("UPPER(name)+city+SUBSTR(name,1,2)+STR(qty,5)+DTOS(born)+'ab'", e.String())
+
+	width, err := e.Width(func(name string) (int, error) {
+		return 10, nil
+	})
+	t.Nil(err)
+	t.Equal(10+10+2+5+8+2, width)
+}
+
+func (t *exprTestSuite) TestErrors() {
+	for _, src := range []string{"", "UPPER(", "UPPER(name", "NOPE(name)", "UPPER(name, city)", "name city", "'open", "name @ city", "qty >", ".FOO.", "NOT", "1.2.3"} {
+		_, err := Parse(src)
+		t.Error(err, src)
+	}
+
+	e, err := Parse("UPPER(qty)")
+	t.Nil(err)
+	_, err = e.Eval(t.resolve)
+	t.EqualError(err, "UPPER expects a text, got int64")
+
+	e, err = Parse("DTOS(city)")
+	t.Nil(err)
+	_, err = e.Eval(t.resolve)
+	t.Error(err)
+
+	e, err = Parse("STR(qty, qty)")
+	t.Nil(err)
+	_, err = e.Width(func(string) (int, error) { return 0, nil })
+	t.Error(err)
+}
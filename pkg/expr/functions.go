@@ -0,0 +1,209 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	defaultStrLength = 10
+	dateLength       = 8
+)
+
+type function struct {
+	minArgs int
+	maxArgs int
+	eval    func(name string, args []interface{}) (interface{}, error)
+	width   func(args []node, sizes Sizer) (int, error)
+}
+
+var functions = map[string]function{
+	"UPPER":  {minArgs: 1, maxArgs: 1, eval: upper, width: firstArgWidth},
+	"LOWER":  {minArgs: 1, maxArgs: 1, eval: lower, width: firstArgWidth},
+	"TRIM":   {minArgs: 1, maxArgs: 1, eval: trim, width: firstArgWidth},
+	"SUBSTR": {minArgs: 2, maxArgs: 3, eval: substr, width: substrWidth},
+	"STR":    {minArgs: 1, maxArgs: 3, eval: str, width: strWidth},
+	"DTOS":   {minArgs: 1, maxArgs: 1, eval: dtos, width: fixedWidth(dateLength)},
+}
+
+func upper(name string, args []interface{}) (interface{}, error) {
+	s, err := toText(name, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.ToUpper(s), nil
+}
+
+func lower(name string, args []interface{}) (interface{}, error) {
+	s, err := toText(name, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.ToLower(s), nil
+}
+
+func trim(name string, args []interface{}) (interface{}, error) {
+	s, err := toText(name, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.TrimRight(s, " "), nil
+}
+
+// substr returns the part of the text from the 1 based start position, counted in characters
+func substr(name string, args []interface{}) (interface{}, error) {
+	s, err := toText(name, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := toInt(name, args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	runes := []rune(s)
+	if start < 1 {
+		start = 1
+	}
+	if start > len(runes) {
+		return "", nil
+	}
+	runes = runes[start-1:]
+
+	if len(args) == 3 {
+		length, err := toInt(name, args[2])
+		if err != nil {
+			return nil, err
+		}
+		if length < 0 {
+			length = 0
+		}
+		if length < len(runes) {
+			runes = runes[:length]
+		}
+	}
+
+	return string(runes), nil
+}
+
+// str formats the number right aligned to the length with the decimals, a number not fitting the length gives asterisks
+func str(name string, args []interface{}) (interface{}, error) {
+	length, decimals := defaultStrLength, 0
+	var err error
+	if len(args) > 1 {
+		length, err = toInt(name, args[1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(args) > 2 {
+		decimals, err = toInt(name, args[2])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if args[0] == nil {
+		return strings.Repeat(" ", length), nil
+	}
+
+	num, err := toNumber(name, args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	s := fmt.Sprintf("%*.*f", length, decimals, num)
+	if len(s) > length {
+		return strings.Repeat("*", length), nil
+	}
+
+	return s, nil
+}
+
+// dtos formats the date as YYYYMMDD, an empty date gives spaces
+func dtos(name string, args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return strings.Repeat(" ", dateLength), nil
+	}
+
+	date, ok := args[0].(time.Time)
+	if !ok {
+		return nil, fmt.Errorf("%s expects a date, got %T", name, args[0])
+	}
+
+	return date.Format("20060102"), nil
+}
+
+func firstArgWidth(args []node, sizes Sizer) (int, error) {
+	return args[0].width(sizes)
+}
+
+func fixedWidth(width int) func([]node, Sizer) (int, error) {
+	return func([]node, Sizer) (int, error) {
+		return width, nil
+	}
+}
+
+func substrWidth(args []node, sizes Sizer) (int, error) {
+	if len(args) == 3 {
+		if l, ok := args[2].(*literal); ok {
+			if length, ok := l.value.(int64); ok {
+				return int(length), nil
+			}
+		}
+	}
+
+	return args[0].width(sizes)
+}
+
+func strWidth(args []node, _ Sizer) (int, error) {
+	if len(args) == 1 {
+		return defaultStrLength, nil
+	}
+
+	if l, ok := args[1].(*literal); ok {
+		if length, ok := l.value.(int64); ok {
+			return int(length), nil
+		}
+	}
+
+	return 0, fmt.Errorf("function STR expects a number literal as length")
+}
+
+// toText converts the argument to text, a missing (nil) value is an empty text
+func toText(name string, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	}
+
+	return "", fmt.Errorf("%s expects a text, got %T", name, value)
+}
+
+func toNumber(name string, value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case int64:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	}
+
+	return 0, fmt.Errorf("%s expects a number, got %T", name, value)
+}
+
+func toInt(name string, value interface{}) (int, error) {
+	num, err := toNumber(name, value)
+
+	return int(num), err
+}
@@ -0,0 +1,123 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tkEOF tokenKind = iota
+	tkIdent
+	tkNumber
+	tkString
+	tkLParen
+	tkRParen
+	tkComma
+	tkPlus
+	tkCompare
+	tkAnd
+	tkOr
+	tkNot
+	tkBool
+)
+
+// keywords are the logical operators and constants written as words or between dots, e.g. AND, .AND., .T.
+var keywords = map[string]tokenKind{
+	"AND":   tkAnd,
+	"OR":    tkOr,
+	"NOT":   tkNot,
+	"TRUE":  tkBool,
+	"FALSE": tkBool,
+	"T":     tkBool,
+	"F":     tkBool,
+}
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// tokenize splits the source to tokens, the last one is always tkEOF
+func tokenize(src string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(src)
+
+	for pos := 0; pos < len(runes); {
+		r := runes[pos]
+		switch {
+		case unicode.IsSpace(r):
+			pos++
+		case r == '(':
+			tokens = append(tokens, token{kind: tkLParen, text: "(", pos: pos})
+			pos++
+		case r == ')':
+			tokens = append(tokens, token{kind: tkRParen, text: ")", pos: pos})
+			pos++
+		case r == ',':
+			tokens = append(tokens, token{kind: tkComma, text: ",", pos: pos})
+			pos++
+		case r == '+':
+			tokens = append(tokens, token{kind: tkPlus, text: "+", pos: pos})
+			pos++
+		case strings.ContainsRune("=<>!#", r):
+			op := string(r)
+			if pos+1 < len(runes) {
+				switch pair := string(runes[pos : pos+2]); pair {
+				case "<=", ">=", "<>", "!=", "==":
+					op = pair
+				}
+			}
+			if op == "!" {
+				tokens = append(tokens, token{kind: tkNot, text: op, pos: pos})
+			} else {
+				tokens = append(tokens, token{kind: tkCompare, text: op, pos: pos})
+			}
+			pos += len(op)
+		case r == '.' && pos+1 < len(runes) && unicode.IsLetter(runes[pos+1]):
+			end := strings.IndexRune(string(runes[pos+1:]), '.')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated operator at position %d", pos)
+			}
+			word := string(runes[pos+1:])[:end]
+			kind, ok := keywords[strings.ToUpper(word)]
+			if !ok {
+				return nil, fmt.Errorf("unknown operator .%s. at position %d", word, pos)
+			}
+			tokens = append(tokens, token{kind: kind, text: strings.ToUpper(word), pos: pos})
+			pos += len([]rune(word)) + 2
+		case r == '\'' || r == '"':
+			end := strings.IndexRune(string(runes[pos+1:]), r)
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated string at position %d", pos)
+			}
+			text := string(runes[pos+1:])[:end]
+			tokens = append(tokens, token{kind: tkString, text: text, pos: pos})
+			pos += len([]rune(text)) + 2
+		case unicode.IsDigit(r) || r == '.':
+			start := pos
+			for pos < len(runes) && (unicode.IsDigit(runes[pos]) || runes[pos] == '.') {
+				pos++
+			}
+			tokens = append(tokens, token{kind: tkNumber, text: string(runes[start:pos]), pos: start})
+		case unicode.IsLetter(r) || r == '_':
+			start := pos
+			for pos < len(runes) && (unicode.IsLetter(runes[pos]) || unicode.IsDigit(runes[pos]) || runes[pos] == '_') {
+				pos++
+			}
+			word := string(runes[start:pos])
+			if kind, ok := keywords[strings.ToUpper(word)]; ok && len(word) > 1 {
+				tokens = append(tokens, token{kind: kind, text: strings.ToUpper(word), pos: start})
+				continue
+			}
+			tokens = append(tokens, token{kind: tkIdent, text: word, pos: start})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", r, pos)
+		}
+	}
+
+	return append(tokens, token{kind: tkEOF, pos: len(runes)}), nil
+}
@@ -13,6 +13,8 @@ import (
 const (
 	// Int64Length is the generic length assigned to int field type
 	Int64Length = 8
+	// Float64Length is the length of the IEEE-754 real field type
+	Float64Length = 8
 	// DefaultFolder is the default database file folder
 	DefaultFolder = "./dbfolder"
 	// PointerRecordLength is int64 + 1 deleted flag
@@ -92,18 +94,15 @@ func (d *fil) OpenReadWrite(fileName string) (*os.File, error) {
 	return file, nil
 }
 
-// ReadBytes read specified amount of bytes from the file from the specified file pointer
+// ReadBytes read specified amount of bytes from the file from the specified file pointer. The reads do not move the
+// file offset, so the cursors of a table can read the same file concurrently
 func (d *fil) ReadBytes(file *os.File, filePointer int64, bytesToRead int) ([]byte, bool, error) {
-	_, err := file.Seek(filePointer, io.SeekStart)
-	if err != nil {
-		return nil, false, errSeekFile
-	}
-
 	buffer := make([]byte, bytesToRead)
-	_, err = file.Read(buffer)
+	n, err := file.ReadAt(buffer, filePointer)
 	if err != nil {
 		if err == io.EOF {
-			return nil, true, nil
+			// a partial read at the end of the file is not eof, the rest of the buffer stays zero
+			return buffer, n == 0, nil
 		}
 		return nil, false, errReadFile
 	}
@@ -113,12 +112,7 @@ func (d *fil) ReadBytes(file *os.File, filePointer int64, bytesToRead int) ([]by
 
 // WriteBytes writes a byte buffer to a specified pointer
 func (d *fil) WriteBytes(file *os.File, filePointer int64, buf []byte) error {
-	_, err := file.Seek(filePointer, io.SeekStart)
-	if err != nil {
-		return errSeekFile
-	}
-
-	_, err = file.Write(buf)
+	_, err := file.WriteAt(buf, filePointer)
 	if err != nil {
 		return errWriteFile
 	}
@@ -143,11 +137,6 @@ func (d *fil) AppendBytes(file *os.File, buf []byte) (int64, error) {
 
 // WriteInt64 write int to a specific file pointer
 func (d *fil) WriteInt64(file *os.File, filePointer int64, num int64) error {
-	_, err := file.Seek(filePointer, io.SeekStart)
-	if err != nil {
-		return errSeekFile
-	}
-
 	// Might need to compare performance the above is more platform safe but may be less effective
 	// buf := make([]byte, Int64Length) // Allocate 8 bytes since int64 is 8 bytes
 	// binary.BigEndian.PutUint64(buf, uint64(num)) // Convert int64 to uint64 for byte conversion
@@ -156,7 +145,7 @@ func (d *fil) WriteInt64(file *os.File, filePointer int64, num int64) error {
 	buf := make([]byte, 8)
 	binary.LittleEndian.PutUint64(buf, uint64(num))
 
-	_, err = file.Write(buf)
+	_, err := file.WriteAt(buf, filePointer)
 	if err != nil {
 		return errWriteFile
 	}
@@ -166,18 +155,16 @@ func (d *fil) WriteInt64(file *os.File, filePointer int64, num int64) error {
 
 // ReadInt64 reads an int64 from a specific file pointer
 func (d *fil) ReadInt64(file *os.File, filePointer int64) (int64, bool, error) {
-	_, err := file.Seek(filePointer, io.SeekStart)
-	if err != nil {
-		return 0, false, errSeekFile
-	}
-
 	buf := make([]byte, Int64Length)
-	_, err = file.Read(buf)
+	n, err := file.ReadAt(buf, filePointer)
 	if err != nil {
-		if err == io.EOF {
+		if err != io.EOF {
+			return 0, false, errReadFile
+		}
+
+		if n == 0 {
 			return 0, true, nil
 		}
-		return 0, false, errReadFile
 	}
 
 	// num := int64(binary.BigEndian.Uint64(buf)) // This is the safe but may be less performant way, read desc above, swap if other swapped
@@ -0,0 +1,574 @@
+// Package hash implements an on-disk linear hash index for equality lookups, the keys have no order
+package hash
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	filemanager "godb/pkg/file"
+	"hash/fnv"
+	"os"
+)
+
+const (
+	hashFileExt = ".hsh"
+
+	// The header: magic, key size, entries per page, level, split pointer, entry count, directory pointer,
+	// directory capacity, free page list
+	headerLength   = 64
+	initialBuckets = 4
+	initialDirCap  = 16
+	pageSize       = 4096
+	pageHeader     = 16 // next overflow page pointer, entry count, reserved
+	minPageEntries = 4
+	// a bucket is split when the entries fill more than loadFactor percent of the primary pages
+	loadFactor = 80
+)
+
+var headerMagic = []byte("GODBHSH1")
+
+// New opens (or creates) the hash index file, the keys are padded or cut to the key size
+func New(indexName string, keySize int) (Hash, error) {
+	if keySize <= 0 {
+		return nil, fmt.Errorf("hash index %s key size must be positive", indexName)
+	}
+
+	pageEntries := (pageSize - pageHeader) / (keySize + filemanager.Int64Length)
+	if pageEntries < minPageEntries {
+		pageEntries = minPageEntries
+	}
+
+	h := &hashTable{
+		filer:       filemanager.New(),
+		indexName:   indexName,
+		keySize:     keySize,
+		pageEntries: pageEntries,
+	}
+
+	err := h.init()
+	if err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// Hash is the interface of the hash index, a key may have more record pointers
+type Hash interface {
+	Insert([]byte, int64) error
+	Search([]byte) ([]int64, error)
+	Exists([]byte) (bool, error)
+	Delete([]byte, int64) error
+	Count() int64
+	Reset() error
+	Close() error
+}
+
+// hashTable is a linear hash table: the buckets are split one by one in order (split pointer) as the table grows,
+// every bucket is a chain of pages. The directory of the bucket pages is kept in memory and written through
+type hashTable struct {
+	filer       filemanager.Filer
+	indexName   string
+	file        *os.File
+	keySize     int
+	pageEntries int
+	level       int64
+	next        int64
+	count       int64
+	dirPtr      int64
+	dirCap      int64
+	freePtr     int64
+	directory   []int64
+}
+
+// page is a page of a bucket chain
+type page struct {
+	ptr     int64
+	next    int64
+	keys    [][]byte
+	records []int64
+}
+
+// Insert adds the key with the record pointer
+func (h *hashTable) Insert(key []byte, recordPtr int64) error {
+	k := h.key(key)
+	pages, err := h.chain(h.bucket(k))
+	if err != nil {
+		return err
+	}
+
+	last := pages[len(pages)-1]
+	if len(last.keys) == h.pageEntries {
+		overflow, err := h.allocate()
+		if err != nil {
+			return err
+		}
+
+		last.next = overflow.ptr
+		err = h.writePage(last)
+		if err != nil {
+			return err
+		}
+		last = overflow
+	}
+
+	last.keys = append(last.keys, k)
+	last.records = append(last.records, recordPtr)
+	err = h.writePage(last)
+	if err != nil {
+		return err
+	}
+
+	h.count++
+	if h.count*100 > int64(len(h.directory)*h.pageEntries*loadFactor) {
+		return h.split()
+	}
+
+	return h.writeHeader()
+}
+
+// Search returns the record pointers of the key
+func (h *hashTable) Search(key []byte) ([]int64, error) {
+	k := h.key(key)
+	pages, err := h.chain(h.bucket(k))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]int64, 0)
+	for _, p := range pages {
+		for x, pk := range p.keys {
+			if bytes.Equal(pk, k) {
+				result = append(result, p.records[x])
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Exists reports if the key is in the index
+func (h *hashTable) Exists(key []byte) (bool, error) {
+	records, err := h.Search(key)
+
+	return len(records) > 0, err
+}
+
+// Delete removes the key of the record pointer, the last entry of the chain takes it's place
+func (h *hashTable) Delete(key []byte, recordPtr int64) error {
+	k := h.key(key)
+	pages, err := h.chain(h.bucket(k))
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pages {
+		for x, pk := range p.keys {
+			if !bytes.Equal(pk, k) || p.records[x] != recordPtr {
+				continue
+			}
+
+			last := pages[len(pages)-1]
+			end := len(last.keys) - 1
+			p.keys[x], p.records[x] = last.keys[end], last.records[end]
+			last.keys, last.records = last.keys[:end], last.records[:end]
+
+			if p != last {
+				err = h.writePage(p)
+				if err != nil {
+					return err
+				}
+			}
+
+			err = h.writePage(last)
+			if err != nil {
+				return err
+			}
+
+			// An empty overflow page is unlinked and goes to the free list
+			if len(last.keys) == 0 && len(pages) > 1 {
+				prev := pages[len(pages)-2]
+				prev.next = 0
+				err = h.writePage(prev)
+				if err != nil {
+					return err
+				}
+
+				err = h.free(last)
+				if err != nil {
+					return err
+				}
+			}
+
+			h.count--
+			return h.writeHeader()
+		}
+	}
+
+	return nil
+}
+
+// Count returns the number of keys in the index
+func (h *hashTable) Count() int64 {
+	return h.count
+}
+
+// Reset removes every key from the index
+func (h *hashTable) Reset() error {
+	err := h.file.Truncate(0)
+	if err != nil {
+		return err
+	}
+
+	return h.initTable()
+}
+
+func (h *hashTable) Close() error {
+	return h.file.Close()
+}
+
+// key pads (or cuts) the key to the key size
+func (h *hashTable) key(key []byte) []byte {
+	k := make([]byte, h.keySize)
+	copy(k, key)
+
+	return k
+}
+
+// bucket addresses the key by the current level, the buckets before the split pointer are already split
+func (h *hashTable) bucket(key []byte) int64 {
+	hasher := fnv.New64a()
+	hasher.Write(key)
+	sum := hasher.Sum64()
+
+	size := uint64(initialBuckets) << h.level
+	b := sum % size
+	if int64(b) < h.next {
+		b = sum % (size * 2)
+	}
+
+	return int64(b)
+}
+
+// split rehashes the bucket at the split pointer to itself and a new bucket at the end of the directory
+func (h *hashTable) split() error {
+	size := int64(initialBuckets) << h.level
+	pages, err := h.chain(h.next)
+	if err != nil {
+		return err
+	}
+
+	newPage, err := h.allocate()
+	if err != nil {
+		return err
+	}
+
+	err = h.addBucket(newPage.ptr)
+	if err != nil {
+		return err
+	}
+
+	oldKeys, oldRecords := make([][]byte, 0), make([]int64, 0)
+	newKeys, newRecords := make([][]byte, 0), make([]int64, 0)
+	h.next++
+	for _, p := range pages {
+		for x, k := range p.keys {
+			if h.bucket(k) == h.next-1 {
+				oldKeys, oldRecords = append(oldKeys, k), append(oldRecords, p.records[x])
+			} else {
+				newKeys, newRecords = append(newKeys, k), append(newRecords, p.records[x])
+			}
+		}
+	}
+
+	// The pages of the old chain are reused, the pages not needed anymore go to the free list
+	spare, err := h.fillChain(pages, oldKeys, oldRecords)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range spare {
+		err = h.free(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = h.fillChain([]*page{newPage}, newKeys, newRecords)
+	if err != nil {
+		return err
+	}
+
+	if h.next == size {
+		h.level++
+		h.next = 0
+	}
+
+	return h.writeHeader()
+}
+
+// fillChain writes the entries to the pages of the chain, allocates more pages when needed, returns the unused pages
+func (h *hashTable) fillChain(pages []*page, keys [][]byte, records []int64) ([]*page, error) {
+	used := 0
+	for start := 0; start < len(keys) || used == 0; start += h.pageEntries {
+		end := min(start+h.pageEntries, len(keys))
+
+		var p *page
+		if used < len(pages) {
+			p = pages[used]
+		} else {
+			var err error
+			p, err = h.allocate()
+			if err != nil {
+				return nil, err
+			}
+
+			prev := pages[used-1]
+			prev.next = p.ptr
+			err = h.writePage(prev)
+			if err != nil {
+				return nil, err
+			}
+			pages = append(pages, p)
+		}
+		used++
+
+		p.keys, p.records = keys[start:end], records[start:end]
+		p.next = 0
+		if end < len(keys) && used < len(pages) {
+			p.next = pages[used].ptr
+		}
+
+		err := h.writePage(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pages[used:], nil
+}
+
+// chain reads the pages of the bucket
+func (h *hashTable) chain(bucket int64) ([]*page, error) {
+	pages := make([]*page, 0, 1)
+	for ptr := h.directory[bucket]; ptr != 0; {
+		p, err := h.readPage(ptr)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, p)
+		ptr = p.next
+	}
+
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("hash index %s bucket %d has no page, corrupt index file", h.indexName, bucket)
+	}
+
+	return pages, nil
+}
+
+func (h *hashTable) readPage(ptr int64) (*page, error) {
+	buf, eof, err := h.filer.ReadBytes(h.file, ptr, h.pageLength())
+	if err != nil {
+		return nil, err
+	}
+
+	if eof {
+		return nil, fmt.Errorf("hash index %s page %d is beyond the end of file, corrupt index file", h.indexName, ptr)
+	}
+
+	count := int(binary.LittleEndian.Uint32(buf[8:]))
+	p := &page{
+		ptr:     ptr,
+		next:    int64(binary.LittleEndian.Uint64(buf)),
+		keys:    make([][]byte, count),
+		records: make([]int64, count),
+	}
+
+	entryLength := h.keySize + filemanager.Int64Length
+	for x := 0; x < count; x++ {
+		offset := pageHeader + x*entryLength
+		p.keys[x] = buf[offset : offset+h.keySize]
+		p.records[x] = int64(binary.LittleEndian.Uint64(buf[offset+h.keySize:]))
+	}
+
+	return p, nil
+}
+
+func (h *hashTable) writePage(p *page) error {
+	buf := make([]byte, h.pageLength())
+	binary.LittleEndian.PutUint64(buf, uint64(p.next))
+	binary.LittleEndian.PutUint32(buf[8:], uint32(len(p.keys)))
+
+	entryLength := h.keySize + filemanager.Int64Length
+	for x, k := range p.keys {
+		offset := pageHeader + x*entryLength
+		copy(buf[offset:], k)
+		binary.LittleEndian.PutUint64(buf[offset+h.keySize:], uint64(p.records[x]))
+	}
+
+	return h.filer.WriteBytes(h.file, p.ptr, buf)
+}
+
+// allocate returns an empty page from the free list or from the end of the file
+func (h *hashTable) allocate() (*page, error) {
+	if h.freePtr != 0 {
+		p, err := h.readPage(h.freePtr)
+		if err != nil {
+			return nil, err
+		}
+		h.freePtr = p.next
+
+		return &page{ptr: p.ptr}, nil
+	}
+
+	ptr, err := h.filer.AppendBytes(h.file, make([]byte, h.pageLength()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &page{ptr: ptr}, nil
+}
+
+// free puts the page to the head of the free list
+func (h *hashTable) free(p *page) error {
+	p.keys, p.records = nil, nil
+	p.next = h.freePtr
+	h.freePtr = p.ptr
+
+	return h.writePage(p)
+}
+
+// addBucket appends the page of the new bucket to the directory, a full directory moves to the end of the file with
+// double capacity
+func (h *hashTable) addBucket(ptr int64) error {
+	h.directory = append(h.directory, ptr)
+	if int64(len(h.directory)) > h.dirCap {
+		h.dirCap *= 2
+		dirPtr, err := h.filer.AppendBytes(h.file, make([]byte, h.dirCap*filemanager.Int64Length))
+		if err != nil {
+			return err
+		}
+		h.dirPtr = dirPtr
+
+		return h.writeDirectory()
+	}
+
+	bucket := int64(len(h.directory) - 1)
+
+	return h.filer.WriteInt64(h.file, h.dirPtr+bucket*filemanager.Int64Length, ptr)
+}
+
+func (h *hashTable) writeDirectory() error {
+	buf := make([]byte, len(h.directory)*filemanager.Int64Length)
+	for x, ptr := range h.directory {
+		binary.LittleEndian.PutUint64(buf[x*filemanager.Int64Length:], uint64(ptr))
+	}
+
+	return h.filer.WriteBytes(h.file, h.dirPtr, buf)
+}
+
+func (h *hashTable) pageLength() int {
+	return pageHeader + h.pageEntries*(h.keySize+filemanager.Int64Length)
+}
+
+func (h *hashTable) init() error {
+	fileName := h.indexName + hashFileExt
+	newFile, err := h.filer.CreateBlankFileIfNotExist(fileName)
+	if err != nil {
+		return err
+	}
+
+	file, err := h.filer.OpenReadWrite(fileName)
+	if err != nil {
+		return err
+	}
+	h.file = file
+
+	if newFile {
+		return h.initTable()
+	}
+
+	return h.readHeader()
+}
+
+// initTable writes the header, the directory and the pages of the initial buckets to the blank file
+func (h *hashTable) initTable() error {
+	h.level, h.next, h.count, h.freePtr = 0, 0, 0, 0
+	h.dirPtr, h.dirCap = headerLength, initialDirCap
+	h.directory = make([]int64, initialBuckets)
+
+	err := h.filer.WriteBytes(h.file, 0, make([]byte, headerLength+initialDirCap*filemanager.Int64Length))
+	if err != nil {
+		return err
+	}
+
+	for x := range h.directory {
+		p, err := h.allocate()
+		if err != nil {
+			return err
+		}
+
+		err = h.writePage(p)
+		if err != nil {
+			return err
+		}
+		h.directory[x] = p.ptr
+	}
+
+	err = h.writeDirectory()
+	if err != nil {
+		return err
+	}
+
+	return h.writeHeader()
+}
+
+func (h *hashTable) writeHeader() error {
+	buf := make([]byte, headerLength)
+	copy(buf, headerMagic)
+	binary.LittleEndian.PutUint32(buf[8:], uint32(h.keySize))
+	binary.LittleEndian.PutUint32(buf[12:], uint32(h.pageEntries))
+	for x, num := range []int64{h.level, h.next, h.count, h.dirPtr, h.dirCap, h.freePtr} {
+		binary.LittleEndian.PutUint64(buf[16+x*filemanager.Int64Length:], uint64(num))
+	}
+
+	return h.filer.WriteBytes(h.file, 0, buf)
+}
+
+// readHeader reads the header and the directory, the key size and page size of the file are used
+func (h *hashTable) readHeader() error {
+	buf, eof, err := h.filer.ReadBytes(h.file, 0, headerLength)
+	if err != nil {
+		return err
+	}
+
+	if eof || !bytes.Equal(buf[:len(headerMagic)], headerMagic) {
+		return fmt.Errorf("hash index %s has no valid header, corrupt index file", h.indexName)
+	}
+
+	h.keySize = int(binary.LittleEndian.Uint32(buf[8:]))
+	h.pageEntries = int(binary.LittleEndian.Uint32(buf[12:]))
+	nums := make([]int64, 6)
+	for x := range nums {
+		nums[x] = int64(binary.LittleEndian.Uint64(buf[16+x*filemanager.Int64Length:]))
+	}
+	h.level, h.next, h.count, h.dirPtr, h.dirCap, h.freePtr = nums[0], nums[1], nums[2], nums[3], nums[4], nums[5]
+
+	buckets := (int64(initialBuckets) << h.level) + h.next
+	buf, eof, err = h.filer.ReadBytes(h.file, h.dirPtr, int(buckets)*filemanager.Int64Length)
+	if err != nil {
+		return err
+	}
+
+	if eof {
+		return fmt.Errorf("hash index %s directory is beyond the end of file, corrupt index file", h.indexName)
+	}
+
+	h.directory = make([]int64, buckets)
+	for x := range h.directory {
+		h.directory[x] = int64(binary.LittleEndian.Uint64(buf[x*filemanager.Int64Length:]))
+	}
+
+	return nil
+}
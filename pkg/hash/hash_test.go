@@ -0,0 +1,130 @@
+package hash
+
+import (
+	"fmt"
+	filemanager "godb/pkg/file"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type hashTestSuite struct {
+	suite.Suite
+	index Hash
+}
+
+func TestHashRunner(t *testing.T) {
+	suite.Run(t, new(hashTestSuite))
+}
+
+func (t *hashTestSuite) SetupTest() {
+	err := os.RemoveAll(filemanager.DefaultFolder)
+	if err != nil {
+		panic("Cannot run test, the folder cannot be removed " + err.Error())
+	}
+
+	t.index, err = New("test_hash", 10)
+	if err != nil {
+		panic(err.Error())
+	}
+}
+
+func (t *hashTestSuite) TearDownTest() {
+	t.index.Close()
+}
+
+func (t *hashTestSuite) TestInsertAndSearch() {
+	for i := 0; i < 5000; i++ {
+		err := t.index.Insert([]byte(fmt.Sprintf("key%d", i)), int64(i))
+		t.Nil(err)
+	}
+
+	// Duplicated keys have more record pointers
+	for i := 0; i < 300; i++ {
+		err := t.index.Insert([]byte("dup"), int64(10000+i))
+		t.Nil(err)
+	}
+	t.Equal(int64(5300), t.index.Count())
+
+	for i := 0; i < 5000; i++ {
+		records, err := t.index.Search([]byte(fmt.Sprintf("key%d", i)))
+		t.Nil(err)
+		t.Equal([]int64{int64(i)}, records)
+	}
+
+	records, err := t.index.Search([]byte("dup"))
+	t.Nil(err)
+	t.Len(records, 300)
+
+	found, err := t.index.Exists([]byte("missing"))
+	t.Nil(err)
+	t.False(found)
+
+	// Keys are padded to the key size, longer ones are cut
+	found, err = t.index.Exists([]byte("key1\x00"))
+	t.Nil(err)
+	t.True(found)
+}
+
+func (t *hashTestSuite) TestDeleteAndReopen() {
+	for i := 0; i < 3000; i++ {
+		err := t.index.Insert([]byte(fmt.Sprintf("key%d", i%1000)), int64(i))
+		t.Nil(err)
+	}
+
+	for i := 0; i < 3000; i += 2 {
+		err := t.index.Delete([]byte(fmt.Sprintf("key%d", i%1000)), int64(i))
+		t.Nil(err)
+	}
+
+	// Deleting a missing pointer does nothing
+	err := t.index.Delete([]byte("key1"), 2)
+	t.Nil(err)
+	t.Equal(int64(1500), t.index.Count())
+
+	err = t.index.Close()
+	t.Nil(err)
+	t.index, err = New("test_hash", 10)
+	t.Nil(err)
+	t.Equal(int64(1500), t.index.Count())
+
+	records, err := t.index.Search([]byte("key1"))
+	t.Nil(err)
+	t.ElementsMatch([]int64{1, 1001, 2001}, records)
+
+	records, err = t.index.Search([]byte("key2"))
+	t.Nil(err)
+	t.Empty(records)
+
+	records, err = t.index.Search([]byte("key3"))
+	t.Nil(err)
+	t.ElementsMatch([]int64{3, 1003, 2003}, records)
+
+	// The emptied overflow pages are reused
+	for i := 0; i < 500; i++ {
+		err := t.index.Insert([]byte("dup"), int64(i))
+		t.Nil(err)
+	}
+	info, err := os.Stat(filemanager.DefaultFolder + "/test_hash" + hashFileExt)
+	t.Nil(err)
+
+	for i := 0; i < 500; i++ {
+		err := t.index.Delete([]byte("dup"), int64(i))
+		t.Nil(err)
+	}
+	for i := 0; i < 500; i++ {
+		err := t.index.Insert([]byte("dup"), int64(i))
+		t.Nil(err)
+	}
+	after, err := os.Stat(filemanager.DefaultFolder + "/test_hash" + hashFileExt)
+	t.Nil(err)
+	t.Equal(info.Size(), after.Size())
+
+	err = t.index.Reset()
+	t.Nil(err)
+	t.Equal(int64(0), t.index.Count())
+	found, err := t.index.Exists([]byte("key1"))
+	t.Nil(err)
+	t.False(found)
+}
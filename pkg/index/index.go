@@ -1,9 +1,11 @@
-// Package index will create and maintain an index file
+// Package index will create and maintain a binary tree index file
 package index
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
+	"godb/pkg/btree"
 	filemanager "godb/pkg/file"
 	"io"
 	"os"
@@ -11,45 +13,81 @@ import (
 
 const (
 	indexFileExt = ".idx"
-	equal        = 0
-	smaller      = -1
-	bigger       = 1
+
+	// The header: magic, buffer size, key type, flags, 2 reserved bytes, root, smallest and largest node pointers.
+	// Pointer 0 is the null pointer, the nodes follow the header
+	headerLength   = 40
+	rootPtr        = 16
+	smallestPtr    = 24
+	largestPtr     = 32
+	flagNullable   = 1
+	flagDescending = 2
 )
 
-var nullPointers = make([]byte, filemanager.Int64Length*3)
+var (
+	headerMagic  = []byte("GODBBIN1")
+	nullPointers = make([]byte, filemanager.Int64Length*3)
+)
 
-// New indexer
+// New indexer with text keys
 func New(indexName string, bufSize int) (Indexer, error) {
-	i := &ind{
+	return NewWithOptions(indexName, bufSize, btree.Options{KeyType: btree.KeyText})
+}
+
+// NewWithOptions creates an indexer ordering it's keys like a btree with the same options
+func NewWithOptions(indexName string, bufSize int, opts btree.Options) (Indexer, error) {
+	i := &ind{tree: &tree{
 		filer:     filemanager.New(),
 		indexName: indexName,
 		bufSize:   bufSize,
-	}
+		opts:      opts,
+	}}
 
 	err := i.init()
+	if err != nil {
+		return nil, err
+	}
 
-	return i, err
+	return i, nil
 }
 
-// Indexer interface will represent the abstracted indexing logic
+// Indexer interface will represent the abstracted indexing logic, the navigation methods are the same as the btree ones
 type Indexer interface {
-	Insert(int64, []byte) error
+	Insert([]byte, int64) error
 	Seek([]byte) ([]int64, error)
+	Search([]byte) (int64, *[]byte, bool, error)
+	Exists([]byte) (bool, error)
+	First() (int64, *[]byte, error)
+	Last() (int64, *[]byte, error)
+	Next() (int64, *[]byte, bool, error)
+	Prev() (int64, *[]byte, bool, error)
+	Delete([]byte, int64) error
+	Reset() error
+	Close() error
+	Cursor() Indexer
 }
 
+// ind is an unbalanced binary tree, every node holds a key, the left and right node pointers and the list of
+// the record pointers (mapping) of the key. The cursor is a node and an item of it's mapping list
 type ind struct {
-	filer             filemanager.Filer
-	file              *os.File
-	fileName          string
-	indexName         string
-	bufSize           int
-	mappingPointer    int64
-	smallestNodePtr   int64
-	largestNodePtr    int64
+	*tree
 	currentNodePtr    int64
 	currentMappingPtr int64
-	smallestNodeValue []byte
-	largestNodeValue  []byte
+	currentValue      []byte
+}
+
+// tree is the file and the header of the binary tree, shared by the cursors
+type tree struct {
+	filer           filemanager.Filer
+	file            *os.File
+	fileName        string
+	indexName       string
+	bufSize         int
+	opts            btree.Options
+	mappingPointer  int64
+	rootNodePtr     int64
+	smallestNodePtr int64
+	largestNodePtr  int64
 }
 
 func (i *ind) init() error {
@@ -59,10 +97,12 @@ func (i *ind) init() error {
 		return err
 	}
 
-	i.smallestNodeValue = make([]byte, i.bufSize)
-	i.largestNodeValue = make([]byte, i.bufSize)
+	err = i.openIndexFile()
+	if err != nil {
+		return err
+	}
 
-	return i.openIndexFile()
+	return i.readHeader()
 }
 
 func (i *ind) createIndexFileIfNotExists() error {
@@ -81,136 +121,486 @@ func (i *ind) openIndexFile() error {
 	return nil
 }
 
-func (i *ind) Insert(mappingPointer int64, data []byte) error {
+// readHeader reads the node pointers and the options of the file, a blank file gets the header. Files written before
+// the header had the root node at offset 0, they have to be rebuilt
+func (i *ind) readHeader() error {
+	buf, eof, err := i.filer.ReadBytes(i.file, 0, headerLength)
+	if err != nil {
+		return err
+	}
+
+	if eof {
+		return i.writeHeader()
+	}
+
+	if !bytes.Equal(buf[:len(headerMagic)], headerMagic) {
+		return fmt.Errorf("index file %s has no header, it was created by an older version, rebuild it", i.fileName)
+	}
+
+	// An empty index gets the options of the caller
+	if binary.LittleEndian.Uint64(buf[rootPtr:]) == 0 {
+		return i.Reset()
+	}
+
+	i.bufSize = int(binary.LittleEndian.Uint32(buf[8:]))
+	i.opts.KeyType = btree.KeyType(buf[12])
+	i.opts.Nullable = buf[13]&flagNullable != 0
+	i.opts.Descending = buf[13]&flagDescending != 0
+	i.rootNodePtr = int64(binary.LittleEndian.Uint64(buf[rootPtr:]))
+	i.smallestNodePtr = int64(binary.LittleEndian.Uint64(buf[smallestPtr:]))
+	i.largestNodePtr = int64(binary.LittleEndian.Uint64(buf[largestPtr:]))
+
+	return nil
+}
+
+func (i *ind) writeHeader() error {
+	buf := make([]byte, headerLength)
+	copy(buf, headerMagic)
+	binary.LittleEndian.PutUint32(buf[8:], uint32(i.bufSize))
+	buf[12] = byte(i.opts.KeyType)
+	if i.opts.Nullable {
+		buf[13] |= flagNullable
+	}
+
+	if i.opts.Descending {
+		buf[13] |= flagDescending
+	}
+	binary.LittleEndian.PutUint64(buf[rootPtr:], uint64(i.rootNodePtr))
+	binary.LittleEndian.PutUint64(buf[smallestPtr:], uint64(i.smallestNodePtr))
+	binary.LittleEndian.PutUint64(buf[largestPtr:], uint64(i.largestNodePtr))
+
+	return i.filer.WriteBytes(i.file, 0, buf)
+}
+
+// Insert adds the record pointer to the key, the key has to be the size of the buffer
+func (i *ind) Insert(data []byte, mappingPointer int64) error {
 	i.mappingPointer = mappingPointer
 	bufSize := len(data)
 	if bufSize != i.bufSize {
 		return fmt.Errorf("index and buffer size mismatch %d/%d", bufSize, i.bufSize)
 	}
 
-	err := i.insertNode(0, &data)
-
-	return err
+	return i.insertNode(&data)
 }
 
+// Seek returns the record pointers of the key and places the cursor on it
 func (i *ind) Seek(data []byte) ([]int64, error) {
-	mapList, nodePointer, err := i.seekNode(0, &data)
-	i.currentNodePtr = nodePointer
-	i.currentMappingPtr = 0
-	return mapList, err
+	key := i.key(data)
+	nodePointer, _, err := i.seekNode(&key)
+	if err != nil || nodePointer == 0 {
+		return nil, err
+	}
+
+	_, _, _, mappingNode, err := i.readByNodePointer(nodePointer)
+	if err != nil {
+		return nil, err
+	}
+
+	i.setCursor(nodePointer, mappingNode, key)
+
+	return i.getMapValues(mappingNode)
 }
 
-func (i *ind) seekNode(nodePointer int64, data *[]byte) ([]int64, int64, error) {
-	buf, leftNode, rightNode, mappingNode, err := i.readByNodePointer(nodePointer)
+// Search places the cursor on the key, or if not found on the next greater key, or on the last key
+func (i *ind) Search(data []byte) (int64, *[]byte, bool, error) {
+	key := i.key(data)
+	nodePointer, greaterPointer, err := i.seekNode(&key)
 	if err != nil {
-		return nil, nodePointer, err
+		return 0, nil, false, err
 	}
 
-	if buf == nil {
-		// empty index
-		return nil, nodePointer, nil
+	if nodePointer == 0 && greaterPointer == 0 {
+		result, value, err := i.Last()
+		return result, value, false, err
 	}
 
-	compared := i.compareAsStr(buf, data)
-	if compared == equal {
-		mapList, err := i.getMapValues(mappingNode)
-		return mapList, nodePointer, err
+	found := nodePointer != 0
+	if !found {
+		nodePointer = greaterPointer
 	}
 
-	if compared == smaller {
-		if leftNode != 0 {
-			return i.seekNode(leftNode, data)
-		}
-		// Not found on left node
-		return nil, nodePointer, nil
+	result, value, err := i.moveToNode(nodePointer, false)
+
+	return result, value, found, err
+}
+
+// Exists reports if the key is in the index, it does not move the cursor
+func (i *ind) Exists(data []byte) (bool, error) {
+	key := i.key(data)
+	nodePointer, _, err := i.seekNode(&key)
+
+	return nodePointer != 0, err
+}
+
+// First places the cursor on the first record pointer of the smallest key
+func (i *ind) First() (int64, *[]byte, error) {
+	if i.smallestNodePtr == 0 {
+		i.currentNodePtr = 0
+		return 0, nil, nil
 	}
 
-	if compared == bigger {
-		if rightNode != 0 {
-			return i.seekNode(rightNode, data)
-		}
-		// Not found on right node
-		return nil, nodePointer, nil
+	return i.moveToNode(i.smallestNodePtr, false)
+}
+
+// Last places the cursor on the last record pointer of the largest key
+func (i *ind) Last() (int64, *[]byte, error) {
+	if i.largestNodePtr == 0 {
+		i.currentNodePtr = 0
+		return 0, nil, nil
+	}
+
+	return i.moveToNode(i.largestNodePtr, true)
+}
+
+// Next moves the cursor to the next record pointer of the key or to the next key, at the end it stays on the last
+func (i *ind) Next() (int64, *[]byte, bool, error) {
+	if i.currentNodePtr == 0 {
+		return 0, nil, true, nil
+	}
+
+	_, next, err := i.readMapping(i.currentMappingPtr)
+	if err != nil {
+		return 0, nil, false, err
+	}
+
+	if next != 0 {
+		result, _, err := i.readMapping(next)
+		i.currentMappingPtr = next
+		return result, &i.currentValue, false, err
+	}
+
+	nodePointer, err := i.neighbourNode(i.currentValue, true)
+	if err != nil {
+		return 0, nil, false, err
 	}
 
-	// Not found on any node
-	return nil, nodePointer, nil
+	if nodePointer == 0 {
+		_, _, err := i.Last()
+		return 0, nil, true, err
+	}
+
+	result, key, err := i.moveToNode(nodePointer, false)
+
+	return result, key, false, err
 }
 
-func (i *ind) insertNode(nodePointer int64, data *[]byte) error {
-	// TODO if I insert a node, before I update the left or right node pointer need to check if there is already
-	// an attached left or right node, and need to move this node id to the new node, otherwise will break the tree.
-	buf, leftNode, rightNode, mappingNode, err := i.readByNodePointer(nodePointer)
+// Prev moves the cursor to the previous record pointer of the key or to the previous key, at the beginning it stays
+// on the first
+func (i *ind) Prev() (int64, *[]byte, bool, error) {
+	if i.currentNodePtr == 0 {
+		return 0, nil, true, nil
+	}
+
+	_, _, _, mappingNode, err := i.readByNodePointer(i.currentNodePtr)
+	if err != nil {
+		return 0, nil, false, err
+	}
+
+	// The mapping list is single linked, the previous item is searched from the head
+	if mappingNode != i.currentMappingPtr {
+		for mappingNode != 0 {
+			result, next, err := i.readMapping(mappingNode)
+			if err != nil {
+				return 0, nil, false, err
+			}
+
+			if next == i.currentMappingPtr {
+				i.currentMappingPtr = mappingNode
+				return result, &i.currentValue, false, nil
+			}
+			mappingNode = next
+		}
+	}
+
+	nodePointer, err := i.neighbourNode(i.currentValue, false)
 	if err != nil {
+		return 0, nil, false, err
+	}
+
+	if nodePointer == 0 {
+		_, _, err := i.First()
+		return 0, nil, true, err
+	}
+
+	result, key, err := i.moveToNode(nodePointer, true)
+
+	return result, key, false, err
+}
+
+// Delete removes the record pointer of the key, the node is removed with it's last record pointer
+func (i *ind) Delete(data []byte, mappingPointer int64) error {
+	key := i.key(data)
+	nodePointer, linkOffset, err := i.findNode(&key)
+	if err != nil || nodePointer == 0 {
 		return err
 	}
 
-	leftNodeOffset := nodePointer + int64(i.bufSize)
-	rightNodeOffset := nodePointer + int64(i.bufSize) + filemanager.Int64Length
+	_, _, _, mappingNode, err := i.readByNodePointer(nodePointer)
+	if err != nil {
+		return err
+	}
 
-	if buf == nil {
-		// Add root node
-		_, err := i.writeNewNode(data)
+	mappingOffset := nodePointer + int64(i.bufSize) + filemanager.Int64Length*2
+	for mappingNode != 0 {
+		value, next, err := i.readMapping(mappingNode)
 		if err != nil {
 			return err
 		}
 
-		return nil
+		if value == mappingPointer {
+			err = i.writeInt64(next, mappingOffset)
+			if err != nil {
+				return err
+			}
+
+			if mappingOffset == nodePointer+int64(i.bufSize)+filemanager.Int64Length*2 && next == 0 {
+				return i.removeNode(nodePointer, linkOffset)
+			}
+
+			return nil
+		}
+
+		mappingOffset = mappingNode + filemanager.Int64Length
+		mappingNode = next
+	}
+
+	return nil
+}
+
+// Reset removes every key from the index
+func (i *ind) Reset() error {
+	err := i.file.Truncate(0)
+	if err != nil {
+		return err
+	}
+
+	i.rootNodePtr, i.smallestNodePtr, i.largestNodePtr, i.currentNodePtr = 0, 0, 0, 0
+
+	return i.writeHeader()
+}
+
+// Cursor returns an indexer on the same file and header with it's own cursor, the cursor must not be closed
+func (i *ind) Cursor() Indexer {
+	return &ind{tree: i.tree}
+}
+
+// Close closes the index file
+func (i *ind) Close() error {
+	return i.file.Close()
+}
+
+// key pads (or cuts) the key to the buffer size
+func (i *ind) key(data []byte) []byte {
+	key := make([]byte, i.bufSize)
+	copy(key, data)
+
+	return key
+}
+
+func (i *ind) compare(c1, c2 *[]byte) int {
+	return btree.Compare(*c1, *c2, i.opts)
+}
+
+func (i *ind) setCursor(nodePointer, mappingPointer int64, key []byte) {
+	i.currentNodePtr = nodePointer
+	i.currentMappingPtr = mappingPointer
+	i.currentValue = key
+}
+
+// moveToNode places the cursor on the first or last record pointer of the node
+func (i *ind) moveToNode(nodePointer int64, last bool) (int64, *[]byte, error) {
+	buf, _, _, mappingNode, err := i.readByNodePointer(nodePointer)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	result, next, err := i.readMapping(mappingNode)
+	if err != nil {
+		return 0, nil, err
 	}
 
-	compared := i.compareAsStr(buf, data)
-	if compared == equal {
-		// TODO already found, add new mapping ony (unique logic will come here)
-		_, err := i.mapValue(mappingNode)
+	for last && next != 0 {
+		mappingNode = next
+		result, next, err = i.readMapping(mappingNode)
 		if err != nil {
-			return err
+			return 0, nil, err
 		}
-
-		return nil
 	}
 
-	if compared == smaller {
-		if leftNode != 0 {
-			return i.insertNode(leftNode, data)
+	i.setCursor(nodePointer, mappingNode, *buf)
+
+	return result, &i.currentValue, nil
+}
+
+// seekNode returns the node of the key, if not found the node of the next greater key, 0 if none
+func (i *ind) seekNode(data *[]byte) (int64, int64, error) {
+	var greater int64
+	nodePointer := i.rootNodePtr
+	for nodePointer != 0 {
+		buf, leftNode, rightNode, _, err := i.readByNodePointer(nodePointer)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		compared := i.compare(data, buf)
+		if compared == 0 {
+			return nodePointer, 0, nil
+		}
+
+		if compared < 0 {
+			greater = nodePointer
+			nodePointer = leftNode
+		} else {
+			nodePointer = rightNode
 		}
-		// Add as left node
-		offset, err := i.writeNewNode(data)
+	}
+
+	return 0, greater, nil
+}
+
+// neighbourNode returns the node of the next greater (or the previous smaller) key, 0 at the end
+func (i *ind) neighbourNode(data []byte, greater bool) (int64, error) {
+	var result int64
+	nodePointer := i.rootNodePtr
+	for nodePointer != 0 {
+		buf, leftNode, rightNode, _, err := i.readByNodePointer(nodePointer)
 		if err != nil {
-			return err
+			return 0, err
 		}
 
-		return i.writeInt64(offset, leftNodeOffset)
+		compared := i.compare(&data, buf)
+		switch {
+		case greater && compared < 0:
+			result = nodePointer
+			nodePointer = leftNode
+		case greater:
+			nodePointer = rightNode
+		case compared > 0:
+			result = nodePointer
+			nodePointer = rightNode
+		default:
+			nodePointer = leftNode
+		}
 	}
 
-	if compared == bigger {
-		if rightNode != 0 {
-			return i.insertNode(rightNode, data)
+	return result, nil
+}
+
+// findNode returns the node of the key and the offset of the pointer linking it to the tree (root or parent),
+// if not found the node pointer is 0 and the offset is where the key has to be linked
+func (i *ind) findNode(data *[]byte) (int64, int64, error) {
+	linkOffset := int64(rootPtr)
+	nodePointer := i.rootNodePtr
+	for nodePointer != 0 {
+		buf, leftNode, rightNode, _, err := i.readByNodePointer(nodePointer)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		compared := i.compare(data, buf)
+		if compared == 0 {
+			return nodePointer, linkOffset, nil
 		}
-		// Add as right node
-		offset, err := i.writeNewNode(data)
+
+		if compared < 0 {
+			linkOffset = nodePointer + int64(i.bufSize)
+			nodePointer = leftNode
+		} else {
+			linkOffset = nodePointer + int64(i.bufSize) + filemanager.Int64Length
+			nodePointer = rightNode
+		}
+	}
+
+	return 0, linkOffset, nil
+}
+
+func (i *ind) insertNode(data *[]byte) error {
+	nodePointer, linkOffset, err := i.findNode(data)
+	if err != nil {
+		return err
+	}
+
+	if nodePointer != 0 {
+		// already found, add new mapping only (unique logic is done by the caller)
+		_, _, _, mappingNode, err := i.readByNodePointer(nodePointer)
 		if err != nil {
 			return err
 		}
 
-		return i.writeInt64(offset, rightNodeOffset)
+		_, err = i.mapValue(mappingNode)
+
+		return err
 	}
 
-	return nil
+	// Not found, the link of the parent (or the root pointer) is the free place of the key
+	offset, err := i.writeNewNode(data)
+	if err != nil {
+		return err
+	}
+
+	return i.writeLink(offset, linkOffset)
 }
 
-func (i *ind) compareAsStr(c1, c2 *[]byte) int {
-	s1 := string(*c1)
-	s2 := string(*c2)
+// removeNode unlinks the node, a node with two children takes the key and mapping of it's successor instead,
+// then the successor is unlinked. The space of the removed nodes is reclaimed by Reset (index rebuild)
+func (i *ind) removeNode(nodePointer, linkOffset int64) error {
+	_, leftNode, rightNode, _, err := i.readByNodePointer(nodePointer)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case leftNode == 0:
+		err = i.writeLink(rightNode, linkOffset)
+	case rightNode == 0:
+		err = i.writeLink(leftNode, linkOffset)
+	default:
+		successor := rightNode
+		successorLink := nodePointer + int64(i.bufSize) + filemanager.Int64Length
+		for {
+			_, left, _, _, err := i.readByNodePointer(successor)
+			if err != nil {
+				return err
+			}
+
+			if left == 0 {
+				break
+			}
+			successorLink = successor + int64(i.bufSize)
+			successor = left
+		}
+
+		buf, _, successorRight, mappingNode, err := i.readByNodePointer(successor)
+		if err != nil {
+			return err
+		}
+
+		err = i.filer.WriteBytes(i.file, nodePointer, *buf)
+		if err != nil {
+			return err
+		}
+
+		err = i.writeInt64(mappingNode, nodePointer+int64(i.bufSize)+filemanager.Int64Length*2)
+		if err != nil {
+			return err
+		}
 
-	if s1 == s2 {
-		return equal
+		err = i.writeLink(successorRight, successorLink)
 	}
+	if err != nil {
+		return err
+	}
+
+	return i.updateIndexStats()
+}
 
-	if s1 > s2 {
-		return smaller
+// writeLink writes the node pointer to the parent node or to the root pointer of the header
+func (i *ind) writeLink(nodePointer, linkOffset int64) error {
+	if linkOffset == rootPtr {
+		i.rootNodePtr = nodePointer
 	}
 
-	return bigger
+	return i.writeInt64(nodePointer, linkOffset)
 }
 
 func (i *ind) readByNodePointer(nodePointer int64) (*[]byte, int64, int64, int64, error) {
@@ -221,12 +611,7 @@ func (i *ind) readByNodePointer(nodePointer int64) (*[]byte, int64, int64, int64
 		return nil, 0, 0, 0, err
 	}
 
-	if eof && nodePointer == 0 {
-		// this is a non existent root node
-		return nil, 0, 0, 0, nil
-	}
-
-	if eof && nodePointer > 0 {
+	if eof {
 		return nil, 0, 0, 0, fmt.Errorf("corrupt index file %s", i.fileName)
 	}
 
@@ -247,7 +632,9 @@ func (i *ind) writeNewNode(data *[]byte) (int64, error) {
 	if err != nil {
 		return 0, err
 	}
-	buf := append(*data, nullPointers...)
+
+	buf := make([]byte, 0, len(*data)+len(nullPointers))
+	buf = append(append(buf, *data...), nullPointers...)
 
 	err = i.filer.WriteBytes(i.file, offset, buf)
 	if err != nil {
@@ -264,9 +651,7 @@ func (i *ind) writeNewNode(data *[]byte) (int64, error) {
 		return offset, err
 	}
 
-	i.updateIndexStats(offset, data)
-
-	return offset, err
+	return offset, i.newNodeStats(offset, data)
 }
 
 func (i *ind) writeInt64(num, offset int64) error {
@@ -290,64 +675,116 @@ func (i *ind) mapValue(mappingNode int64) (int64, error) {
 			nextPointerBuf := make([]byte, filemanager.Int64Length)
 			valueBuf = append(valueBuf, nextPointerBuf...)
 
-			i.filer.WriteBytes(i.file, offset, valueBuf)
+			err = i.filer.WriteBytes(i.file, offset, valueBuf)
+			if err != nil {
+				return 0, err
+			}
 
 			if previousMappingNode != 0 {
 				// Join new value to the mapping list
-				i.writeInt64(offset, previousMappingNode+filemanager.Int64Length)
+				err = i.writeInt64(offset, previousMappingNode+filemanager.Int64Length)
+				if err != nil {
+					return 0, err
+				}
 			}
 
 			return offset, nil
 		}
 		previousMappingNode = mappingNode
 
-		buf, eof, err := i.filer.ReadBytes(i.file, mappingNode, filemanager.Int64Length*2)
+		_, next, err := i.readMapping(mappingNode)
 		if err != nil {
 			return 0, err
 		}
+		mappingNode = next
+	}
+}
 
-		mappingNode = int64(binary.LittleEndian.Uint64(buf[filemanager.Int64Length : filemanager.Int64Length*2]))
+// readMapping reads the record pointer and the next item pointer of the mapping list item
+func (i *ind) readMapping(mappingNode int64) (int64, int64, error) {
+	buf, eof, err := i.filer.ReadBytes(i.file, mappingNode, filemanager.Int64Length*2)
+	if err != nil {
+		return 0, 0, err
+	}
 
-		if eof {
-			return 0, fmt.Errorf("invalid index file %s, mapping buffer write error", i.fileName)
-		}
+	if eof {
+		return 0, 0, fmt.Errorf("invalid index file %s, mapping buffer read error", i.fileName)
 	}
+
+	return int64(binary.LittleEndian.Uint64(buf[:filemanager.Int64Length])),
+		int64(binary.LittleEndian.Uint64(buf[filemanager.Int64Length : filemanager.Int64Length*2])),
+		nil
 }
 
 func (i *ind) getMapValues(mappingNode int64) ([]int64, error) {
 	res := make([]int64, 0)
-	for {
-		if mappingNode == 0 {
-			break
-		}
-
-		buf, eof, err := i.filer.ReadBytes(i.file, mappingNode, filemanager.Int64Length*2)
+	for mappingNode != 0 {
+		value, next, err := i.readMapping(mappingNode)
 		if err != nil {
 			return res, err
 		}
 
-		res = append(res, int64(binary.LittleEndian.Uint64(buf[:filemanager.Int64Length])))
-		mappingNode = int64(binary.LittleEndian.Uint64(buf[filemanager.Int64Length : filemanager.Int64Length*2]))
-
-		if eof {
-			return res, fmt.Errorf("invalid index file %s, mapping buffer read error", i.fileName)
-		}
+		res = append(res, value)
+		mappingNode = next
 	}
 
 	return res, nil
 }
 
-func (i *ind) updateIndexStats(ptr int64, data *[]byte) {
-	// Todo this should be preserved and readed back on index open
-	comp := i.compareAsStr(data, &i.smallestNodeValue)
+// newNodeStats keeps the smallest and largest node pointers of the header up to date on insert
+func (i *ind) newNodeStats(ptr int64, data *[]byte) error {
+	if i.smallestNodePtr == 0 {
+		i.smallestNodePtr, i.largestNodePtr = ptr, ptr
+		return i.writeHeader()
+	}
+
+	smallest, _, _, _, err := i.readByNodePointer(i.smallestNodePtr)
+	if err != nil {
+		return err
+	}
 
-	if comp == smaller {
+	if i.compare(data, smallest) < 0 {
 		i.smallestNodePtr = ptr
 	}
 
-	comp = i.compareAsStr(data, &i.largestNodeValue)
+	largest, _, _, _, err := i.readByNodePointer(i.largestNodePtr)
+	if err != nil {
+		return err
+	}
 
-	if comp == bigger {
+	if i.compare(data, largest) > 0 {
 		i.largestNodePtr = ptr
 	}
+
+	return i.writeHeader()
+}
+
+// updateIndexStats finds the smallest (leftmost) and largest (rightmost) nodes after a node was removed
+func (i *ind) updateIndexStats() error {
+	i.smallestNodePtr, i.largestNodePtr = i.rootNodePtr, i.rootNodePtr
+	for i.smallestNodePtr != 0 {
+		_, leftNode, _, _, err := i.readByNodePointer(i.smallestNodePtr)
+		if err != nil {
+			return err
+		}
+
+		if leftNode == 0 {
+			break
+		}
+		i.smallestNodePtr = leftNode
+	}
+
+	for i.largestNodePtr != 0 {
+		_, _, rightNode, _, err := i.readByNodePointer(i.largestNodePtr)
+		if err != nil {
+			return err
+		}
+
+		if rightNode == 0 {
+			break
+		}
+		i.largestNodePtr = rightNode
+	}
+
+	return i.writeHeader()
 }
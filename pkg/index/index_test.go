@@ -38,14 +38,14 @@ func (t *indexerTestSuite) TearDownTest() {
 
 func (t *indexerTestSuite) TestIndex() {
 	// Assert error out if buf size incorrect
-	err := t.indexer.Insert(int64(255), []byte("123"))
+	err := t.indexer.Insert([]byte("123"), int64(255))
 	t.Error(err)
 	t.Equal("index and buffer size mismatch 3/5", err.Error())
 
-	err = t.indexer.Insert(int64(255), []byte("12345"))
+	err = t.indexer.Insert([]byte("12345"), int64(255))
 	t.Nil(err)
 
-	err = t.indexer.Insert(int64(685), []byte("54321"))
+	err = t.indexer.Insert([]byte("54321"), int64(685))
 	t.Nil(err)
 
 	res, err := t.indexer.Seek([]byte("54321"))
@@ -58,15 +58,15 @@ func (t *indexerTestSuite) TestIterateAndSeek() {
 
 	for i := 0; i < 1000; i++ {
 		str := generateRandomString(5)
-		t.indexer.Insert(int64(121454), []byte(str))
+		t.indexer.Insert([]byte(str), int64(121454))
 	}
 
-	err := t.indexer.Insert(int64(578488), []byte("BLABL"))
+	err := t.indexer.Insert([]byte("BLABL"), int64(578488))
 	t.Nil(err)
 
 	for i := 0; i < 30; i++ {
 		str := generateRandomString(5)
-		t.indexer.Insert(int64(121454), []byte(str))
+		t.indexer.Insert([]byte(str), int64(121454))
 	}
 
 	res, err := t.indexer.Seek([]byte("BLABL"))
@@ -75,6 +75,103 @@ func (t *indexerTestSuite) TestIterateAndSeek() {
 	t.Equal(int64(578488), res[0])
 }
 
+func (t *indexerTestSuite) walk() []int64 {
+	values := make([]int64, 0)
+	value, key, err := t.indexer.First()
+	t.Nil(err)
+	if key == nil {
+		return values
+	}
+
+	for {
+		values = append(values, value)
+		var eof bool
+		value, _, eof, err = t.indexer.Next()
+		t.Nil(err)
+		if eof {
+			return values
+		}
+	}
+}
+
+func (t *indexerTestSuite) insertKeys() {
+	for x, key := range []string{"mmmmm", "ddddd", "ttttt", "aaaaa", "hhhhh", "ppppp", "zzzzz", "ddddd"} {
+		err := t.indexer.Insert([]byte(key), int64(x))
+		t.Nil(err)
+	}
+}
+
+func (t *indexerTestSuite) TestInOrder() {
+	t.insertKeys()
+	t.Equal([]int64{3, 1, 7, 4, 0, 5, 2, 6}, t.walk())
+
+	value, key, err := t.indexer.Last()
+	t.Nil(err)
+	t.Equal(int64(6), value)
+	t.Equal("zzzzz", string(*key))
+
+	values := []int64{value}
+	for {
+		var eof bool
+		value, _, eof, err = t.indexer.Prev()
+		t.Nil(err)
+		if eof {
+			break
+		}
+		values = append(values, value)
+	}
+	t.Equal([]int64{6, 2, 5, 0, 4, 7, 1, 3}, values)
+
+	// Search stops on the next greater key when not found
+	value, key, found, err := t.indexer.Search([]byte("e"))
+	t.Nil(err)
+	t.False(found)
+	t.Equal(int64(4), value)
+	t.Equal("hhhhh", string(*key))
+
+	value, _, eof, err := t.indexer.Next()
+	t.Nil(err)
+	t.False(eof)
+	t.Equal(int64(0), value)
+}
+
+func (t *indexerTestSuite) TestDelete() {
+	t.insertKeys()
+
+	// A duplicate, a node with two children (the root), a leaf and the smallest
+	for _, del := range []struct {
+		key   string
+		value int64
+	}{{"ddddd", 1}, {"mmmmm", 0}, {"zzzzz", 6}, {"aaaaa", 3}} {
+		err := t.indexer.Delete([]byte(del.key), del.value)
+		t.Nil(err)
+	}
+	t.Equal([]int64{7, 4, 5, 2}, t.walk())
+
+	exists, err := t.indexer.Exists([]byte("mmmmm"))
+	t.Nil(err)
+	t.False(exists)
+
+	res, err := t.indexer.Seek([]byte("ddddd"))
+	t.Nil(err)
+	t.Equal([]int64{7}, res)
+
+	// The smallest and largest nodes are kept in the file
+	t.Nil(t.indexer.Close())
+	t.indexer, err = New("test_index", 5)
+	t.Nil(err)
+	value, _, err := t.indexer.First()
+	t.Nil(err)
+	t.Equal(int64(7), value)
+	value, _, err = t.indexer.Last()
+	t.Nil(err)
+	t.Equal(int64(2), value)
+
+	err = t.indexer.Reset()
+	t.Nil(err)
+	t.Empty(t.walk())
+}
+
 func generateRandomString(length int) string {
 	charset := "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 	seededRand := rand.New(rand.NewSource(uint64(time.Now().UnixNano())))
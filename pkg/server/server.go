@@ -21,8 +21,9 @@ type Stat struct {
 }
 
 type RecordStatus struct {
-	Eof bool `json:"eof"`
-	Bof bool `json:"bof"`
+	Eof   bool `json:"eof"`
+	Bof   bool `json:"bof"`
+	Found bool `json:"found"`
 }
 
 type server struct {
@@ -45,6 +46,7 @@ func Serve(db localdb.Manager, table *localdb.CurrentTable) {
 	http.HandleFunc("/insert", server.handlerInsert)
 	http.HandleFunc("/seek", server.handlerSeek)
 	http.HandleFunc("/delete", server.handlerDelete)
+	http.HandleFunc("/recall", server.handlerRecall)
 
 	err := http.ListenAndServe(":8080", nil)
 	if err != nil {
@@ -189,13 +191,15 @@ func (s *server) handlerInsert(w http.ResponseWriter, r *http.Request) {
 
 func (s *server) handlerSeek(w http.ResponseWriter, r *http.Request) {
 	val := r.URL.Query().Get("value")
-	err := s.db.Seek(s.table, val)
+	found, err := s.db.Seek(s.table, val)
 
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(&AppError{Error: err.Error(), Code: http.StatusInternalServerError})
 		return
 	}
+
+	json.NewEncoder(w).Encode(&RecordStatus{Eof: s.table.Eof(), Found: found})
 }
 
 func (s *server) handlerDelete(w http.ResponseWriter, r *http.Request) {
@@ -212,3 +216,19 @@ func (s *server) handlerDelete(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(&AppError{Error: err.Error(), Code: http.StatusInternalServerError})
 	}
 }
+
+func (s *server) handlerRecall(w http.ResponseWriter, r *http.Request) {
+	val := r.URL.Query().Get("recNo")
+	rn, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(&AppError{Error: err.Error(), Code: http.StatusInternalServerError})
+		return
+	}
+
+	err = s.db.Recall(s.table, rn)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(&AppError{Error: err.Error(), Code: http.StatusInternalServerError})
+	}
+}